@@ -2,57 +2,202 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"math/big"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"sun-DappBackend-homework/config"
 	"sun-DappBackend-homework/internal/blockchain"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 func main() {
-	// 加载配置
-	cfg := config.LoadConfig()
+	err := run()
+	// json 格式的记录在退出时合并为一个数组写出；出错前已得到的记录同样输出
+	if ferr := blockchain.FlushOutput(); ferr != nil && err == nil {
+		err = fmt.Errorf("写出结构化输出失败: %v", ferr)
+	}
+	if err != nil {
+		// run 返回前已执行其中的 defer (关闭节点、缓存统计与积分报告)，这里只记录错误并以非零状态退出
+		log.Print(err)
+		os.Exit(1)
+	}
+}
 
+// run 解析参数并执行所选模式。所有错误都通过返回值传回 main，确保 defer 的清理与报告在失败时也会执行
+func run() error {
 	// 解析命令行参数
-	mode := flag.String("mode", "", "运行模式: 'query', 'tx', 'deploy', 'increment', 'count', 'subscribe', 'subscribe-logs'")
-	blockNum := flag.Int64("block", 0, "要查询的区块号 (默认: 最新区块) 或 订阅模式的起始扫描高度")
+	mode := flag.String("mode", "", "运行模式: 'query', 'txinfo', 'receipt', 'tx', 'build-tx', 'sign-tx', 'broadcast', 'payout', 'deploy', 'deployments', 'increment', 'count', 'count-history', 'balance', 'account', 'activity', 'gas', 'endpoints', 'call', 'send', 'subscribe', 'subscribe-logs'")
+	blockFlag := flag.String("block", "", "区块号、区块哈希或 latest/safe/finalized 标签 (默认: 最新区块)；订阅模式下为起始扫描高度")
 	toAddr := flag.String("to", "", "交易接收方地址")
-	amount := flag.Float64("amount", 0.0, "发送的 ETH 金额")
-	contractAddr := flag.String("contract", "", "交互的合约地址")
+	amount := flag.String("amount", "", "发送的 ETH 金额，十进制字符串，最多 18 位小数 (如 0.1)")
+	contractAddr := flag.String("contract", "", "交互的合约地址，或网络配置 / 部署记录中的合约名称")
+	fromAddr := flag.String("from", "", "构建未签名交易的发送方地址 (默认: PRIVATE_KEY 对应的地址)")
+	chainID := flag.Uint64("chain-id", 0, "期望的链 ID (默认使用网络配置中的 chain_id；离线签名时必须提供其一)")
+	inFile := flag.String("file", "", "输入文件路径")
+	outFile := flag.String("out", "", "输出文件路径")
+	rawTx := flag.String("raw", "", "已签名交易的 RLP 十六进制")
+	dryRun := flag.Bool("dry-run", false, "只模拟并打印摘要，不签名也不发送交易")
+	assumeYes := flag.Bool("yes", false, "跳过交互式确认 (包括主网写操作的确认)")
+	abiFile := flag.String("abi", "", "合约 ABI 文件 (call / send 模式)")
+	method := flag.String("method", "", "合约方法名或签名，方法参数跟在所有选项之后")
+	deployName := flag.String("name", "counter", "部署时记录的合约名称")
+	txHash := flag.String("hash", "", "交易哈希 (txinfo / receipt 模式)")
+	address := flag.String("address", "", "查询的账户地址 (activity 模式可用逗号分隔多个)")
+	token := flag.String("token", "", "ERC-20 代币合约地址 (account 模式可用逗号分隔多个，默认使用 TOKEN_LIST)")
+	slots := flag.String("slots", "", "account 模式读取的存储槽，逗号分隔 (槽号或 32 字节槽位键)")
+	startBlock := flag.Uint64("start-block", 0, "区块范围的起始区块 (包含，activity / count-history 模式必填)")
+	endBlock := flag.Uint64("end-block", 0, "区块范围的结束区块 (包含，默认: 最新区块)")
+	spotChecks := flag.Int("spot-checks", 3, "count-history 模式中使用历史 GetCount 抽查的点数")
+	gasBlocks := flag.Int("blocks", blockchain.DefaultGasHistoryBlocks, "gas 模式分析的最近区块数")
+	watch := flag.Bool("watch", false, "gas 模式下订阅新区块并持续更新 (需要 INFURA_WS_URL)")
+	registryPath := flag.String("registry", blockchain.DefaultRegistryPath, "部署记录文件路径")
+	salt := flag.String("salt", "", "CREATE2 盐值 (32 字节十六进制或任意字符串)，指定后通过 CREATE2 工厂确定性部署")
+	artifact := flag.String("artifact", "Counter", "CREATE2 部署的内置合约名称")
+	detail := flag.Bool("detail", false, "query 模式下列出区块内每笔交易及费用、Blob Gas 和提款汇总")
+	output := flag.String("output", "text", "输出格式: text / json / ndjson / csv (非 text 时 stdout 只输出结构化数据，提示信息写入 stderr)")
+	receipts := flag.Bool("receipts", false, "query -detail 模式下同时获取交易回执 (执行状态、实际 Gas、日志数量)")
+	credits := flag.Bool("credits", false, "退出时按 RPC 方法列出本次运行消耗的积分")
+	logFormat := flag.String("log-format", "text", "日志格式: text / json (日志始终写入 stderr，与 stdout 上的数据分开)")
+	logLevel := flag.String("log-level", "info", "日志级别: debug / info / warn / error")
+	network := flag.String("network", "", "网络配置文件中的网络名称，如 mainnet / sepolia / local (默认: NETWORK 环境变量或配置文件中的 default)")
+	networksFile := flag.String("config", config.DefaultNetworksFile, "网络配置文件路径")
 
 	flag.Parse()
 
+	if err := blockchain.SetOutputFormat(*output); err != nil {
+		return err
+	}
+
+	// 所有日志统一经过 slog 写入 stderr：config 包通过 LoadOptions.Logger 使用同一个 logger，main 中的 log.Print 经由 slog.SetDefault
+	logger, err := blockchain.NewLogger(blockchain.LogOptions{Format: *logFormat, Level: *logLevel})
+	if err != nil {
+		return err
+	}
+	blockchain.SetLogger(logger)
+	slog.SetDefault(logger)
+	slog.SetLogLoggerLevel(slog.LevelError) // log 包只用于报告 run 返回的错误
+
 	if *mode == "" {
-		fmt.Println("请使用 -mode 参数指定运行模式。")
-		fmt.Println("可用模式: query, tx, deploy, increment, count, subscribe, subscribe-logs")
-		fmt.Println("示例:")
-		fmt.Println("  go run cmd/main.go -mode query -block 123456")
-		fmt.Println("  go run cmd/main.go -mode tx -to 0xRecipientAddress -amount 0.001")
-		fmt.Println("  go run cmd/main.go -mode deploy")
-		fmt.Println("  go run cmd/main.go -mode increment -contract 0xContractAddress")
-		fmt.Println("  go run cmd/main.go -mode count -contract 0xContractAddress")
-		fmt.Println("  go run cmd/main.go -mode subscribe -block 5430000 (可选: 指定起始高度进行追赶)")
-		fmt.Println("  go run cmd/main.go -mode subscribe-logs -contract 0xContractAddress")
-		os.Exit(1)
+		fmt.Fprintln(blockchain.TextOut(), "请使用 -mode 参数指定运行模式。")
+		fmt.Fprintln(blockchain.TextOut(), "可用模式: query, txinfo, receipt, tx, build-tx, sign-tx, broadcast, payout, deploy, deployments, increment, count, count-history, balance, account, activity, gas, endpoints, call, send, subscribe, subscribe-logs")
+		fmt.Fprintln(blockchain.TextOut(), "示例:")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode query -block 123456")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -network mainnet -mode query (使用 networks.yaml 中的网络配置)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode txinfo -hash 0xTxHash (可选: -abi 解码输入与日志)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode tx -to 0xRecipientAddress -amount 0.001")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode build-tx -from 0xSender -to 0xRecipientAddress -amount 0.001 -out unsigned.json")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode sign-tx -file unsigned.json -chain-id 11155111 -out signed.txt")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode broadcast -file signed.txt")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode payout -file payouts.csv -dry-run")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode deploy -name counter")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode deploy -salt counter-v1 (CREATE2 确定性部署)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode deployments")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode increment -contract 0xContractAddress -dry-run (可选: 仅模拟)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode count -contract counter (可使用部署记录中的名称)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode count -contract counter -block finalized (历史状态读取)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode count-history -contract counter -start-block 5400000 -out history.csv")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode balance -address 0xAccount -block 5432100")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode account -address 0xContractAddress -slots 0 -token 0xToken1,0xToken2")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode activity -address 0xAddr1,0xAddr2 -start-block 5400000 -end-block 5401000 -out activity.csv")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode gas -blocks 50 (可选: -watch 每个新区块更新)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode call -contract 0xContractAddress -abi Token.abi -method balanceOf 0xOwnerAddress")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode send -contract 0xContractAddress -abi Token.abi -method transfer 0xRecipientAddress 1000")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode subscribe -block 5430000 (可选: 指定起始高度进行追赶)")
+		fmt.Fprintln(blockchain.TextOut(), "  go run cmd/main.go -mode subscribe-logs -contract 0xContractAddress")
+		return errors.New("未指定运行模式")
 	}
 
-	// 对于订阅模式，我们不需要立即初始化标准的 HTTP 客户端，
-	// 并且我们需要以不同方式处理信号。
-	if *mode == "subscribe" || *mode == "subscribe-logs" {
-		if cfg.InfuraWSURL == "" {
-			log.Fatal("订阅模式需要 INFURA_WS_URL。请检查您的 .env 文件。")
+	// 离线签名模式在隔离机器上运行，不加载节点配置，也不发起任何 RPC 调用
+	if *mode == "sign-tx" {
+		signerCfg, err := config.LoadSignerConfig(*networksFile, *network, logger)
+		if err != nil {
+			return err
+		}
+		if *chainID == 0 {
+			*chainID = signerCfg.ChainID
 		}
+		if *inFile == "" {
+			return errors.New("离线签名模式请提供 -file 未签名交易文件")
+		}
+		unsigned, err := blockchain.ReadUnsignedTx(*inFile)
+		if err != nil {
+			return err
+		}
+		signedTx, err := blockchain.SignUnsignedTx(unsigned, signerCfg.PrivateKey, *chainID)
+		if err != nil {
+			return fmt.Errorf("离线签名失败: %v", err)
+		}
+		blockchain.PrintTxSummary(signedTx)
+		raw, err := blockchain.EncodeRawTx(signedTx)
+		if err != nil {
+			return err
+		}
+		if !blockchain.IsTextOutput() {
+			record := blockchain.NewTxRecord(signedTx, nil)
+			record.Raw = raw
+			if err := blockchain.Emit(record); err != nil {
+				return err
+			}
+		}
+		if *outFile != "" {
+			if err := os.WriteFile(*outFile, []byte(raw+"\n"), 0o600); err != nil {
+				return fmt.Errorf("写入已签名交易失败: %v", err)
+			}
+			fmt.Fprintf(blockchain.TextOut(), "已签名交易已写入: %s\n", *outFile)
+		} else if blockchain.IsTextOutput() {
+			fmt.Fprintln(blockchain.TextOut(), raw)
+		}
+		return nil
+	}
+
+	// 加载并按模式校验配置，只读模式不要求 PRIVATE_KEY
+	cfg, err := config.LoadConfig(config.LoadOptions{
+		NetworksPath: *networksFile,
+		Network:      *network,
+		Require:      modeRequirements(*mode, *watch, *fromAddr),
+		Logger:       logger,
+	})
+	if err != nil {
+		return err
+	}
+	// 期望的链 ID: -chain-id 优先，其次为网络配置。节点、WebSocket 与签名前的校验都使用这一个值
+	expectedChainID := *chainID
+	if expectedChainID == 0 {
+		expectedChainID = cfg.ChainID
+	}
+	if expectedChainID == 0 {
+		slog.Warn("未配置期望的链 ID (-chain-id、CHAIN_ID 或 -network)，无法确认节点所在的网络")
+	}
+
+	// HTTP 与 WebSocket 请求共享同一个限流器，退出时报告本次运行消耗的积分
+	limiter := blockchain.NewRateLimiter(cfg.CreditsPerSecond, cfg.CreditBudget)
+	defer blockchain.LogCreditUsage(limiter, *credits)
 
-		// 检查 URL 前缀是否为 ws:// 或 wss://
-		// 虽然 ethclient.DialContext 会根据 URL 方案选择传输方式，
-		// 但明确的检查可以帮助用户避免使用 HTTP URL 进行订阅。
-		if len(cfg.InfuraWSURL) < 5 || (cfg.InfuraWSURL[:5] != "ws://" && cfg.InfuraWSURL[:6] != "wss://") {
-			log.Fatal("INFURA_WS_URL 必须以 ws:// 或 wss:// 开头。请检查您的 .env 文件。")
+	blockRef, err := blockchain.ParseBlockRef(*blockFlag)
+	if err != nil {
+		return err
+	}
+
+	// 对于订阅模式，我们不需要立即初始化标准的 HTTP 客户端，
+	// 并且我们需要以不同方式处理信号。
+	if *mode == "subscribe" || *mode == "subscribe-logs" || (*mode == "gas" && *watch) {
+		if !blockchain.SupportsStreaming() {
+			return errors.New("订阅模式会持续输出记录，json 格式只在退出时输出数组，请改用 -output ndjson 或 csv")
 		}
+		// INFURA_WS_URL 已在加载配置时校验 (必须以 ws:// 或 wss:// 开头)，
+		// 多个地址以逗号分隔，断线重连时按优先级依次尝试
 
 		// 创建一个在接收到中断信号时取消的上下文
 		ctx, cancel := context.WithCancel(context.Background())
@@ -63,69 +208,593 @@ func main() {
 
 		go func() {
 			sig := <-sigCh
-			log.Printf("接收到信号: %v。正在关闭...", sig)
+			slog.Info("接收到信号，正在关闭", "signal", sig.String())
 			cancel()
 		}()
 
+		// 订阅模式没有 HTTP 客户端，未配置链 ID 时按名称查找要求名称在所有网络中唯一
+		if *contractAddr != "" {
+			*contractAddr, err = resolveContracts(cfg, *registryPath, *contractAddr, func() (*big.Int, error) {
+				if expectedChainID == 0 {
+					return nil, nil
+				}
+				return new(big.Int).SetUint64(expectedChainID), nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// 订阅模式的起始高度必须是具体的区块号
+		var startBlock int64
+		if !blockRef.IsLatest() {
+			if blockRef.Number == nil || blockRef.Number.Sign() < 0 || !blockRef.Number.IsInt64() {
+				return errors.New("订阅模式的 -block 必须是区块号")
+			}
+			startBlock = blockRef.Number.Int64()
+		}
+
 		if *mode == "subscribe" {
-			blockchain.SubscribeNewHead(ctx, cfg.InfuraWSURL, *blockNum)
+			blockchain.SubscribeNewHead(ctx, cfg.InfuraWSURL, expectedChainID, startBlock, limiter)
 		} else if *mode == "subscribe-logs" {
-			blockchain.SubscribeFilterLogs(ctx, cfg.InfuraWSURL, *contractAddr)
+			blockchain.SubscribeFilterLogs(ctx, cfg.InfuraWSURL, expectedChainID, *contractAddr, limiter)
+		} else {
+			blockchain.WatchGas(ctx, cfg.InfuraWSURL, expectedChainID, *gasBlocks, limiter)
 		}
-		return
+		return nil
 	}
 
 	// 为其他模式连接到以太坊客户端 (HTTP)
-	client := blockchain.GetClient(cfg.InfuraURL)
-	defer blockchain.CloseClient()
+	node, err := blockchain.Dial(context.Background(), limiter, cfg.RPCURLs...)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+	// 网络配置随客户端传递，签名前的链 ID 校验、等待确认数与浏览器链接均从客户端读取
+	node.Network = blockchain.NetworkSettings{
+		ChainID:       expectedChainID,
+		ExplorerURL:   cfg.ExplorerURL,
+		Confirmations: cfg.Confirmations,
+	}
+
+	// 连接后立即校验节点的链 ID，避免在错误的网络上读取数据或签名交易
+	nodeChainID, err := blockchain.VerifyChainID(context.Background(), node, node.Network.ChainID)
+	if err != nil {
+		return fmt.Errorf("节点网络校验失败: %v", err)
+	}
+	slog.Info("节点网络", "network", blockchain.ChainName(nodeChainID), "chain_id", nodeChainID.Uint64())
+
+	// 主网写操作需要显式确认 (批量付款在展示汇总后单独确认)
+	switch *mode {
+	case "tx", "broadcast", "deploy", "increment", "send":
+		if !*dryRun && !blockchain.ConfirmMainnetWrite(nodeChainID, *mode, *assumeYes) {
+			return errors.New("已取消主网写操作")
+		}
+	}
+
+	// 已最终确定的区块、回执与交易走缓存；设置 RPC_CACHE_DIR 后重新运行时复用磁盘缓存
+	client, err := blockchain.NewCachingClient(context.Background(), node, blockchain.CacheOptions{Dir: cfg.CacheDir})
+	if err != nil {
+		return err
+	}
+	defer blockchain.LogCacheStats(client)
+
+	// -contract 可以是网络配置或部署记录中的名称 (多个合约用逗号分隔)，按当前网络的链 ID 解析为地址
+	if *contractAddr != "" {
+		*contractAddr, err = resolveContracts(cfg, *registryPath, *contractAddr, func() (*big.Int, error) {
+			id, err := client.ChainID(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("获取链 ID 失败: %v", err)
+			}
+			return id, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	switch *mode {
 	case "query":
-		if *blockNum == 0 {
-			// 如果未提供区块号，则查询最新区块
-			header, err := client.HeaderByNumber(nil, nil)
+		// 标签与区块哈希先解析为具体区块号；未提供时查询最新区块
+		number, err := blockchain.ResolveBlockNumber(context.Background(), client, blockRef)
+		if err != nil {
+			return fmt.Errorf("获取区块号失败: %v", err)
+		}
+		if blockRef.IsLatest() {
+			fmt.Fprintf(blockchain.TextOut(), "正在查询最新区块: %d\n", number)
+		}
+		if !*detail {
+			if err := blockchain.QueryBlockInfo(client, number.Int64()); err != nil {
+				return fmt.Errorf("查询区块失败: %v", err)
+			}
+			break
+		}
+		if err := blockchain.QueryBlockDetail(client, number.Int64(), *receipts); err != nil {
+			return fmt.Errorf("查询区块详情失败: %v", err)
+		}
+
+	case "txinfo", "receipt":
+		hash, err := parseTxHash(*txHash)
+		if err != nil {
+			return err
+		}
+		if *mode == "receipt" {
+			receipt, err := blockchain.GetReceipt(context.Background(), client, hash)
 			if err != nil {
-				log.Fatalf("获取最新区块号失败: %v", err)
+				return err
+			}
+			if err := blockchain.PrintReceipt(receipt); err != nil {
+				return err
 			}
-			*blockNum = header.Number.Int64()
-			fmt.Printf("正在查询最新区块: %d\n", *blockNum)
+			return nil
+		}
+		var contractABI *abi.ABI
+		if *abiFile != "" {
+			if contractABI, err = blockchain.LoadABIFile(*abiFile); err != nil {
+				return err
+			}
+		}
+		info, err := blockchain.GetTxInfo(context.Background(), client, hash, contractABI)
+		if err != nil {
+			return err
+		}
+		if err := blockchain.PrintTxInfo(info); err != nil {
+			return err
 		}
-		blockchain.QueryBlockInfo(client, *blockNum)
 
 	case "tx":
-		if *toAddr == "" || *amount == 0.0 {
-			log.Fatal("交易模式请提供 -to 和 -amount 参数")
+		value, err := parseAmount(*amount)
+		if err != nil {
+			return err
+		}
+		if *toAddr == "" || value.Sign() == 0 {
+			return errors.New("交易模式请提供 -to 和 -amount 参数")
+		}
+		if *dryRun {
+			if err := blockchain.SimulateSendTransaction(client, cfg.PrivateKey, *toAddr, value); err != nil {
+				return fmt.Errorf("交易模拟失败: %v", err)
+			}
+			return nil
+		}
+		if err := blockchain.SendTransaction(client, cfg.PrivateKey, *toAddr, value); err != nil {
+			return fmt.Errorf("交易失败: %v", err)
+		}
+
+	case "build-tx":
+		value, err := parseAmount(*amount)
+		if err != nil {
+			return err
+		}
+		if *toAddr == "" || value.Sign() == 0 {
+			return errors.New("构建交易模式请提供 -to 和 -amount 参数")
+		}
+		from := *fromAddr
+		if from == "" {
+			signer, err := blockchain.AddressFromPrivateKey(cfg.PrivateKey)
+			if err != nil {
+				return fmt.Errorf("未提供 -from 且无法从私钥推导地址: %v", err)
+			}
+			from = signer.Hex()
+		}
+		unsigned, err := blockchain.BuildUnsignedTx(context.Background(), client, from, *toAddr, value, expectedChainID)
+		if err != nil {
+			return fmt.Errorf("构建未签名交易失败: %v", err)
+		}
+		tx, err := unsigned.Transaction()
+		if err != nil {
+			return err
+		}
+		blockchain.PrintTxSummary(tx)
+		if *outFile == "" {
+			*outFile = "unsigned.json"
+		}
+		if err := blockchain.WriteUnsignedTx(*outFile, unsigned); err != nil {
+			return err
+		}
+		fmt.Fprintf(blockchain.TextOut(), "未签名交易已写入: %s (链 ID: %s, Nonce: %d)\n", *outFile, unsigned.ChainID, unsigned.Nonce)
+		if err := blockchain.Emit(blockchain.NewTxRecord(tx, nil)); err != nil {
+			return err
+		}
+
+	case "broadcast":
+		raw := *rawTx
+		if raw == "" {
+			if *inFile == "" {
+				return errors.New("广播模式请提供 -raw 或 -file 参数")
+			}
+			data, err := os.ReadFile(*inFile)
+			if err != nil {
+				return fmt.Errorf("读取已签名交易失败: %v", err)
+			}
+			raw = string(data)
+		}
+		tx, err := blockchain.BroadcastRawTx(context.Background(), client, raw, expectedChainID)
+		if err != nil {
+			return fmt.Errorf("广播交易失败: %v", err)
+		}
+		blockchain.PrintTxSent(client, "交易已广播", tx)
+
+	case "payout":
+		if *inFile == "" {
+			return errors.New("批量付款模式请提供 -file 付款 CSV 文件")
+		}
+		// 批量付款可能持续较长时间，允许通过 Ctrl+C 中断，结果文件会保留进度
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		err := blockchain.RunPayout(ctx, client, cfg.PrivateKey, blockchain.PayoutOptions{
+			InputPath:  *inFile,
+			ResultPath: *outFile,
+			DryRun:     *dryRun,
+			AssumeYes:  *assumeYes,
+		})
+		if err != nil {
+			return fmt.Errorf("批量付款失败: %v", err)
 		}
-		// 假设私钥在配置中
-		blockchain.SendTransaction(client, cfg.PrivateKey, *toAddr, *amount)
 
 	case "deploy":
-		address, err := blockchain.DeployContract(client, cfg.PrivateKey)
+		if *salt != "" {
+			saltHash, err := blockchain.ParseSalt(*salt)
+			if err != nil {
+				return err
+			}
+			if *dryRun {
+				if err := blockchain.PrintCreate2Prediction(client, *artifact, saltHash); err != nil {
+					return fmt.Errorf("CREATE2 预测失败: %v", err)
+				}
+				return nil
+			}
+			deployment, created, err := blockchain.DeployContractCreate2(client, cfg.PrivateKey, *artifact, *deployName, saltHash)
+			if err != nil {
+				return fmt.Errorf("CREATE2 部署失败: %v", err)
+			}
+			if err := blockchain.RecordDeployment(*registryPath, deployment); err != nil {
+				return fmt.Errorf("写入部署记录失败: %v", err)
+			}
+			if err := blockchain.Emit(deployment); err != nil {
+				return err
+			}
+			if created {
+				fmt.Fprintf(blockchain.TextOut(), "合约部署成功并已校验。合约地址: %s (区块 %d)\n", deployment.Address, deployment.Block)
+			} else {
+				fmt.Fprintf(blockchain.TextOut(), "合约已存在于 %s，未发送交易\n", deployment.Address)
+			}
+			fmt.Fprintf(blockchain.TextOut(), "部署记录已写入 %s，名称: %s\n", *registryPath, deployment.Name)
+			return nil
+		}
+		if *dryRun {
+			if err := blockchain.SimulateDeployContract(client, cfg.PrivateKey); err != nil {
+				return fmt.Errorf("部署模拟失败: %v", err)
+			}
+			return nil
+		}
+		deployment, err := blockchain.DeployContract(client, cfg.PrivateKey, *deployName)
+		if err != nil {
+			return fmt.Errorf("部署合约失败: %v", err)
+		}
+		if err := blockchain.RecordDeployment(*registryPath, deployment); err != nil {
+			return fmt.Errorf("写入部署记录失败: %v", err)
+		}
+		if err := blockchain.Emit(deployment); err != nil {
+			return err
+		}
+		fmt.Fprintf(blockchain.TextOut(), "合约部署成功并已校验。合约地址: %s (区块 %d)\n", deployment.Address, deployment.Block)
+		if link := node.Network.ExplorerAddressURL(deployment.Address); link != "" {
+			fmt.Fprintf(blockchain.TextOut(), "浏览器链接: %s\n", link)
+		}
+		fmt.Fprintf(blockchain.TextOut(), "部署记录已写入 %s，名称: %s\n", *registryPath, deployment.Name)
+
+	case "deployments":
+		reg, err := blockchain.LoadRegistry(*registryPath)
 		if err != nil {
-			log.Fatalf("部署合约失败: %v", err)
+			return err
 		}
-		fmt.Printf("合约部署已启动。交易哈希: %s\n", address)
+		blockchain.PrintDeployments(reg)
 
 	case "increment":
 		if *contractAddr == "" {
-			log.Fatal("增加计数模式请提供 -contract 地址参数")
+			return errors.New("增加计数模式请提供 -contract 地址参数")
+		}
+		if *dryRun {
+			if err := blockchain.SimulateIncrementCounter(client, cfg.PrivateKey, *contractAddr); err != nil {
+				return fmt.Errorf("增加计数模拟失败: %v", err)
+			}
+			return nil
 		}
 		if err := blockchain.IncrementCounter(client, cfg.PrivateKey, *contractAddr); err != nil {
-			log.Fatalf("增加计数器失败: %v", err)
+			return fmt.Errorf("增加计数器失败: %v", err)
 		}
-		fmt.Println("计数器增加成功")
+		fmt.Fprintln(blockchain.TextOut(), "计数器增加成功")
 
 	case "count":
 		if *contractAddr == "" {
-			log.Fatal("查询计数模式请提供 -contract 地址参数")
+			return errors.New("查询计数模式请提供 -contract 地址参数")
+		}
+		// 多个合约时通过 Multicall3 在同一区块批量读取
+		if strings.Contains(*contractAddr, ",") {
+			values, block, err := blockchain.GetCounterValues(client, strings.Split(*contractAddr, ","), blockRef)
+			if err != nil {
+				return fmt.Errorf("批量获取计数器值失败: %v", err)
+			}
+			if err := blockchain.PrintCounterValues(values, block); err != nil {
+				return err
+			}
+			return nil
+		}
+		count, err := blockchain.GetCounterValue(client, *contractAddr, blockRef)
+		if err != nil {
+			return fmt.Errorf("获取计数器值失败: %v", err)
+		}
+		if err := blockchain.PrintCounterValue(*contractAddr, blockRef, count); err != nil {
+			return err
+		}
+
+	case "count-history":
+		if *contractAddr == "" {
+			return errors.New("计数历史模式请提供 -contract 地址参数")
+		}
+		points, err := blockchain.CounterHistory(context.Background(), client, *contractAddr, blockchain.CounterHistoryOptions{
+			StartBlock: *startBlock,
+			EndBlock:   *endBlock,
+			SpotChecks: *spotChecks,
+		})
+		if err != nil {
+			return fmt.Errorf("重建计数历史失败: %v", err)
+		}
+		if *outFile == "" {
+			*outFile = "count_history.csv"
 		}
-		count, err := blockchain.GetCounterValue(client, *contractAddr)
+		if err := blockchain.WriteCounterHistory(*outFile, points); err != nil {
+			return fmt.Errorf("导出计数历史失败: %v", err)
+		}
+		fmt.Fprintf(blockchain.TextOut(), "已导出 %d 个数据点到 %s\n", len(points), *outFile)
+
+	case "balance":
+		if !common.IsHexAddress(*address) {
+			return errors.New("余额查询模式请提供有效的 -address 参数")
+		}
+		account := common.HexToAddress(*address)
+		if *token != "" {
+			if !common.IsHexAddress(*token) {
+				return fmt.Errorf("无效的代币地址: %s", *token)
+			}
+			info, err := blockchain.GetERC20Info(context.Background(), client, common.HexToAddress(*token))
+			if err != nil {
+				return err
+			}
+			balance, err := blockchain.GetERC20Balance(context.Background(), client, info.Address, account, blockRef)
+			if err != nil {
+				return fmt.Errorf("查询代币余额失败: %v", err)
+			}
+			if err := blockchain.PrintBalance(account, blockRef, balance, info); err != nil {
+				return err
+			}
+		} else {
+			balance, err := blockchain.GetBalanceAt(context.Background(), client, account, blockRef)
+			if err != nil {
+				return fmt.Errorf("查询余额失败: %v", err)
+			}
+			if err := blockchain.PrintBalance(account, blockRef, balance, nil); err != nil {
+				return err
+			}
+		}
+
+	case "account":
+		if !common.IsHexAddress(*address) {
+			return errors.New("账户查询模式请提供有效的 -address 参数")
+		}
+		var storageSlots []common.Hash
+		for _, s := range splitList(*slots) {
+			slot, err := blockchain.ParseStorageSlot(s)
+			if err != nil {
+				return err
+			}
+			storageSlots = append(storageSlots, slot)
+		}
+		tokenList := cfg.Tokens
+		if *token != "" {
+			tokenList = splitList(*token)
+		}
+		var tokens []common.Address
+		for _, t := range tokenList {
+			if !common.IsHexAddress(t) {
+				return fmt.Errorf("无效的代币地址: %s", t)
+			}
+			tokens = append(tokens, common.HexToAddress(t))
+		}
+		info, err := blockchain.GetAccountInfo(context.Background(), client, common.HexToAddress(*address), blockRef, storageSlots, tokens)
 		if err != nil {
-			log.Fatalf("获取计数器值失败: %v", err)
+			return fmt.Errorf("查询账户失败: %v", err)
+		}
+		if err := blockchain.PrintAccountInfo(info); err != nil {
+			return err
 		}
-		fmt.Printf("当前计数器值: %s\n", count)
 
+	case "activity":
+		var addresses []common.Address
+		for _, a := range splitList(*address) {
+			if !common.IsHexAddress(a) {
+				return fmt.Errorf("无效的地址: %s", a)
+			}
+			addresses = append(addresses, common.HexToAddress(a))
+		}
+		if len(addresses) == 0 {
+			return errors.New("活动扫描模式请通过 -address 提供至少一个地址")
+		}
+		if *outFile == "" {
+			*outFile = "activity.csv"
+		}
+		// 扫描可能持续较长时间，Ctrl+C 中断后可重新运行从断点继续
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		found, err := blockchain.ScanAddressActivity(ctx, client, blockchain.ActivityScanOptions{
+			Addresses:  addresses,
+			StartBlock: *startBlock,
+			EndBlock:   *endBlock,
+			OutputPath: *outFile,
+		})
+		if err != nil {
+			return fmt.Errorf("地址活动扫描失败 (已找到 %d 条记录): %v", found, err)
+		}
+		fmt.Fprintf(blockchain.TextOut(), "扫描完成，本次找到 %d 条记录，结果文件: %s\n", found, *outFile)
+
+	case "gas":
+		report, err := blockchain.GetGasReport(context.Background(), client, *gasBlocks, nil)
+		if err != nil {
+			return fmt.Errorf("Gas 分析失败: %v", err)
+		}
+		if err := blockchain.PrintGasReport(report); err != nil {
+			return err
+		}
+
+	case "endpoints":
+		statuses := node.EndpointStatuses()
+		if statuses == nil {
+			return errors.New("当前 RPC 地址不是 HTTP(S)，未启用故障转移")
+		}
+		for i := range statuses {
+			s := &statuses[i]
+			if !blockchain.IsTextOutput() {
+				if err := blockchain.Emit(s); err != nil {
+					return err
+				}
+				continue
+			}
+			state := "健康"
+			if !s.Healthy {
+				state = "不健康: " + s.LastError
+			}
+			active := ""
+			if s.Active {
+				active = " [当前]"
+			}
+			fmt.Fprintf(blockchain.TextOut(), "%d. %s%s 高度 %d, 延迟 %s, %s\n", i+1, s.URL, active, s.Height, s.Latency.Round(time.Millisecond), state)
+		}
+
+	case "call":
+		if *contractAddr == "" || *abiFile == "" || *method == "" {
+			return errors.New("call 模式请提供 -contract、-abi 和 -method 参数")
+		}
+		contractABI, err := blockchain.LoadABIFile(*abiFile)
+		if err != nil {
+			return err
+		}
+		m, values, err := blockchain.CallContractMethod(client, *contractAddr, contractABI, *method, flag.Args(), blockRef)
+		if err != nil {
+			return fmt.Errorf("合约调用失败: %v", err)
+		}
+		if err := blockchain.PrintMethodOutputs(m, values); err != nil {
+			return err
+		}
+
+	case "send":
+		if *contractAddr == "" || *abiFile == "" || *method == "" {
+			return errors.New("send 模式请提供 -contract、-abi 和 -method 参数")
+		}
+		contractABI, err := blockchain.LoadABIFile(*abiFile)
+		if err != nil {
+			return err
+		}
+		value, err := parseAmount(*amount)
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			if err := blockchain.SimulateContractMethod(client, cfg.PrivateKey, *contractAddr, contractABI, *method, flag.Args(), value); err != nil {
+				return fmt.Errorf("交易模拟失败: %v", err)
+			}
+			return nil
+		}
+		tx, err := blockchain.SendContractMethod(client, cfg.PrivateKey, *contractAddr, contractABI, *method, flag.Args(), value)
+		if err != nil {
+			return fmt.Errorf("合约交易失败: %v", err)
+		}
+		blockchain.PrintTxSent(client, "交易已发送", tx)
+
+	default:
+		return fmt.Errorf("未知模式: %s", *mode)
+	}
+	return nil
+}
+
+// parseTxHash 校验并解析 -hash 参数
+func parseTxHash(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(strings.TrimSpace(s))
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("请通过 -hash 提供有效的 32 字节交易哈希: %q", s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// parseAmount 将 -amount 的 ETH 金额精确解析为 Wei (不经过浮点运算)，未提供时为 0
+func parseAmount(s string) (*big.Int, error) {
+	if strings.TrimSpace(s) == "" {
+		return new(big.Int), nil
+	}
+	value, err := blockchain.ParseEther(s)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 -amount 参数: %v", err)
+	}
+	return value, nil
+}
+
+// modeRequirements 返回各模式必须提供的配置项：只有签名交易的模式要求 PRIVATE_KEY，
+// 订阅模式只要求 WebSocket 节点
+func modeRequirements(mode string, watch bool, from string) config.Requirements {
+	switch mode {
+	case "subscribe", "subscribe-logs":
+		return config.Requirements{WebSocket: true}
+	case "gas":
+		return config.Requirements{RPC: !watch, WebSocket: watch}
+	case "tx", "payout", "deploy", "increment", "send":
+		return config.Requirements{RPC: true, PrivateKey: true}
+	case "build-tx":
+		// 未提供 -from 时从私钥推导发送方地址
+		return config.Requirements{RPC: true, PrivateKey: from == ""}
 	default:
-		log.Fatalf("未知模式: %s", *mode)
+		return config.Requirements{RPC: true}
+	}
+}
+
+// resolveContracts 将 -contract 中的名称 (多个用逗号分隔) 解析为地址：
+// 先查找所选网络配置中的已知合约，再按链 ID 查找部署记录。chainID 只在需要查找部署记录时调用一次。
+func resolveContracts(cfg *config.Config, registryPath string, value string, chainID func() (*big.Int, error)) (string, error) {
+	var networkID *big.Int
+	names := strings.Split(value, ",")
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		resolved := name
+		if known, ok := cfg.Contracts[name]; ok && !common.IsHexAddress(name) {
+			if !common.IsHexAddress(known) {
+				return "", fmt.Errorf("网络配置中合约 %s 的地址无效: %s", name, known)
+			}
+			resolved = known
+		} else {
+			var err error
+			if !common.IsHexAddress(name) && networkID == nil {
+				if networkID, err = chainID(); err != nil {
+					return "", err
+				}
+			}
+			resolved, err = blockchain.ResolveContractAddress(registryPath, name, networkID)
+			if err != nil {
+				return "", err
+			}
+		}
+		if resolved != name {
+			slog.Info("合约名称已解析", "name", name, "contract", resolved)
+		}
+		names[i] = resolved
+	}
+	return strings.Join(names, ","), nil
+}
+
+// splitList 拆分逗号分隔的参数，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
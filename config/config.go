@@ -1,47 +1,292 @@
 package config
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	InfuraURL   string
-	InfuraWSURL string
+	InfuraURL   string   // 优先级最高的 HTTP 节点
+	RPCURLs     []string // 全部 HTTP 节点 (INFURA_URL 逗号分隔，按优先级排列)，用于故障转移
+	InfuraWSURL string   // WebSocket 节点，可逗号分隔多个，断线重连时按优先级依次尝试
 	PrivateKey  string
+	Tokens      []string // account 模式默认查询的 ERC-20 代币地址 (TOKEN_LIST，逗号分隔)
+
+	CreditsPerSecond float64 // 每秒积分上限 (RPC_CREDITS_PER_SECOND，默认 500，0 表示不限速)
+	CreditBudget     float64 // 单次运行的积分预算 (RPC_CREDIT_BUDGET，默认 0 表示不限)
+	CacheDir         string  // 已最终确定链上数据的磁盘缓存目录 (RPC_CACHE_DIR，为空时只使用内存缓存)
+
+	Network       string            // 所选网络名称，未使用网络配置文件时为空
+	ChainID       uint64            // 期望的链 ID (CHAIN_ID)，0 表示未配置
+	ExplorerURL   string            // 区块浏览器地址 (EXPLORER_URL)
+	Confirmations uint64            // 等待交易上链时要求的确认数 (CONFIRMATIONS，默认 1)
+	Contracts     map[string]string // 网络配置中的已知合约: 名称 -> 地址
+}
+
+// Requirements 运行模式必须提供的配置项；未要求的配置项缺失时不报错，但设置了就必须合法
+type Requirements struct {
+	RPC        bool // HTTP 节点 (INFURA_URL 或网络配置中的 rpc)
+	WebSocket  bool // WebSocket 节点 (INFURA_WS_URL 或网络配置中的 ws)
+	PrivateKey bool // 签名私钥 (PRIVATE_KEY)
+}
+
+// LoadOptions 加载配置的选项
+type LoadOptions struct {
+	NetworksPath string       // 网络配置文件，为空时使用 DefaultNetworksFile
+	Network      string       // 网络名称，为空时依次使用 NETWORK 环境变量和配置文件中的 default
+	Require      Requirements // 当前模式必须提供的配置项
+	Logger       *slog.Logger // 加载过程中的提示 (如跳过的节点地址)，为 nil 时使用 slog.Default()
+}
+
+// FieldError 单个配置项的问题
+type FieldError struct {
+	Field  string // 环境变量名或网络配置文件中的字段路径，如 INFURA_URL、networks.sepolia.rpc
+	Reason string // 不包含私钥等敏感值
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Reason
 }
 
-func LoadConfig() *Config {
-	// 尝试从当前目录加载 .env
-	err := godotenv.Load()
+// ValidationError 汇总配置中所有缺失或格式错误的字段
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Fields)+1)
+	lines = append(lines, fmt.Sprintf("配置无效 (%d 项):", len(e.Fields)))
+	for _, f := range e.Fields {
+		lines = append(lines, "  - "+f.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationError) add(field string, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+// LoadConfig 加载 .env 与网络配置文件中所选的网络，并按 opts.Require 校验。
+// 环境变量 (INFURA_URL、INFURA_WS_URL、TOKEN_LIST、CHAIN_ID、EXPLORER_URL、CONFIRMATIONS) 优先于配置文件。
+// 网络配置文件无法读取时返回该错误；字段缺失或格式错误时一次性返回包含所有问题的 *ValidationError。
+func LoadConfig(opts LoadOptions) (*Config, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	loadDotEnv(logger)
+
+	network := opts.Network
+	if network == "" {
+		network = strings.TrimSpace(os.Getenv("NETWORK"))
+	}
+	profile, err := selectNetwork(opts.NetworksPath, network, logger)
 	if err != nil {
-		// 如果未找到，尝试从父目录加载 (以防从 cmd/ 运行)
+		return nil, err
+	}
+	if profile == nil {
+		profile = &Network{}
+	} else {
+		logger.Info("使用网络配置", "network", profile.Name, "chain_id", profile.ChainID)
+	}
+
+	verr := &ValidationError{}
+	src := sources{network: profile.Name}
+	cfg := &Config{
+		RPCURLs:       splitList(src.get("INFURA_URL", strings.Join(profile.RPC, ","))),
+		InfuraWSURL:   strings.Join(splitList(src.get("INFURA_WS_URL", strings.Join(profile.WS, ","))), ","),
+		PrivateKey:    strings.TrimSpace(os.Getenv("PRIVATE_KEY")),
+		Tokens:        splitList(src.get("TOKEN_LIST", strings.Join(profile.Tokens, ","))),
+		CacheDir:      strings.TrimSpace(os.Getenv("RPC_CACHE_DIR")),
+		Network:       profile.Name,
+		ExplorerURL:   strings.TrimRight(src.get("EXPLORER_URL", profile.Explorer), "/"),
+		Contracts:     profile.Contracts,
+		ChainID:       profile.ChainID,
+		Confirmations: profile.Confirmations,
+	}
+	if len(cfg.RPCURLs) > 0 {
+		cfg.InfuraURL = cfg.RPCURLs[0]
+	}
+	cfg.CreditsPerSecond = envFloat(verr, "RPC_CREDITS_PER_SECOND", 500)
+	cfg.CreditBudget = envFloat(verr, "RPC_CREDIT_BUDGET", 0)
+	cfg.ChainID = envUint(verr, "CHAIN_ID", cfg.ChainID)
+	cfg.Confirmations = max(envUint(verr, "CONFIRMATIONS", cfg.Confirmations), 1)
+
+	cfg.validate(verr, src, opts.Require)
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+	return cfg, nil
+}
+
+// Validate 按 req 校验配置，返回包含所有问题的 *ValidationError；供直接构造 Config 的调用方使用
+func (c *Config) Validate(req Requirements) error {
+	verr := &ValidationError{}
+	c.validate(verr, sources{network: c.Network}, req)
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func (c *Config) validate(verr *ValidationError, src sources, req Requirements) {
+	rpcField := src.field("INFURA_URL", "rpc")
+	if len(c.RPCURLs) == 0 && req.RPC {
+		verr.add(rpcField, "未设置 HTTP 节点地址")
+	}
+	for _, u := range c.RPCURLs {
+		if err := checkURL(u, "http", "https"); err != nil {
+			verr.add(rpcField, "%v", err)
+		}
+	}
+
+	wsField := src.field("INFURA_WS_URL", "ws")
+	wsURLs := splitList(c.InfuraWSURL)
+	if len(wsURLs) == 0 && req.WebSocket {
+		verr.add(wsField, "未设置 WebSocket 节点地址 (订阅模式需要此项)")
+	}
+	for _, u := range wsURLs {
+		if err := checkURL(u, "ws", "wss"); err != nil {
+			verr.add(wsField, "%v", err)
+		}
+	}
+
+	switch {
+	case c.PrivateKey == "":
+		if req.PrivateKey {
+			verr.add("PRIVATE_KEY", "未设置 (签名交易需要此项)")
+		}
+	default:
+		if _, err := crypto.HexToECDSA(strings.TrimPrefix(c.PrivateKey, "0x")); err != nil {
+			verr.add("PRIVATE_KEY", "不是有效的 32 字节十六进制私钥")
+		}
+	}
+
+	for _, t := range c.Tokens {
+		if !common.IsHexAddress(t) {
+			verr.add(src.field("TOKEN_LIST", "tokens"), "无效的代币地址 %q", t)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Contracts)) {
+		if addr := c.Contracts[name]; !common.IsHexAddress(addr) {
+			verr.add(src.field("", "contracts."+name), "无效的合约地址 %q", addr)
+		}
+	}
+	if c.ExplorerURL != "" {
+		if err := checkURL(c.ExplorerURL, "http", "https"); err != nil {
+			verr.add(src.field("EXPLORER_URL", "explorer"), "%v", err)
+		}
+	}
+}
+
+// LoadSignerConfig 仅加载离线签名所需的配置 (PRIVATE_KEY，以及所选网络的链 ID)。
+// 离线签名机器不连接任何节点，因此不要求设置 INFURA_URL。
+func LoadSignerConfig(networksPath string, network string, logger *slog.Logger) (*Config, error) {
+	return LoadConfig(LoadOptions{
+		NetworksPath: networksPath,
+		Network:      network,
+		Require:      Requirements{PrivateKey: true},
+		Logger:       logger,
+	})
+}
+
+// loadDotEnv 从当前目录加载 .env，未找到时尝试父目录 (以防从 cmd/ 运行)
+func loadDotEnv(logger *slog.Logger) {
+	if err := godotenv.Load(); err != nil {
 		if err := godotenv.Load("../.env"); err != nil {
-			log.Println("警告: 当前或父目录未找到 .env 文件，正在从环境变量读取")
+			logger.Warn("当前或父目录未找到 .env 文件，正在从环境变量读取")
 		}
 	}
+}
 
-	infuraURL := os.Getenv("INFURA_URL")
-	if infuraURL == "" {
-		log.Fatal("未设置 INFURA_URL")
+// sources 记录每个配置项的来源，错误信息中指向实际需要修改的环境变量或配置文件字段
+type sources struct {
+	network  string
+	fromFile map[string]bool // 环境变量名 -> 值是否来自配置文件
+}
+
+// get 返回环境变量的值，未设置时返回配置文件中的值
+func (s *sources) get(key string, fileValue string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	if s.fromFile == nil {
+		s.fromFile = map[string]bool{}
+	}
+	s.fromFile[key] = fileValue != ""
+	return fileValue
+}
+
+// field 返回错误信息中使用的字段名
+func (s sources) field(key string, fileField string) string {
+	if (key == "" || s.fromFile[key]) && s.network != "" {
+		return "networks." + s.network + "." + fileField
+	}
+	if key == "" {
+		return fileField
+	}
+	return key
+}
+
+// checkURL 校验地址可以解析且协议在允许范围内
+func checkURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("无效的地址 (需要 %s://主机)", schemes[0])
 	}
+	for _, s := range schemes {
+		if u.Scheme == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的协议 %q (可选: %s)", u.Scheme, strings.Join(schemes, " / "))
+}
 
-	infuraWSURL := os.Getenv("INFURA_WS_URL")
-	// 如果缺少 WS URL，则发出警告但不是致命错误，因为某些模式不需要它
-	if infuraWSURL == "" {
-		log.Println("警告: 未设置 INFURA_WS_URL (订阅模式需要此项)")
+// splitList 拆分逗号分隔的配置项，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	privateKey := os.Getenv("PRIVATE_KEY")
-	if privateKey == "" {
-		log.Fatal("未设置 PRIVATE_KEY")
+// envFloat 读取非负数值配置，未设置时返回默认值
+func envFloat(verr *ValidationError, key string, def float64) float64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
 	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		verr.add(key, "必须是非负数: %q", v)
+		return def
+	}
+	return f
+}
 
-	return &Config{
-		InfuraURL:   infuraURL,
-		InfuraWSURL: infuraWSURL,
-		PrivateKey:  privateKey,
+// envUint 读取非负整数配置，未设置时返回配置文件中的值
+func envUint(verr *ValidationError, key string, fileValue uint64) uint64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fileValue
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		verr.add(key, "必须是非负整数: %q", v)
+		return fileValue
 	}
+	return n
 }
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidateReportsAllFields(t *testing.T) {
+	cfg := &Config{
+		RPCURLs:     []string{"https://rpc.example.com", "wss://rpc.example.com", "rpc.example.com"},
+		InfuraWSURL: "https://ws.example.com",
+		PrivateKey:  "0x1234",
+		Tokens:      []string{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "USDC"},
+		Contracts:   map[string]string{"counter": "0xabc"},
+		ExplorerURL: "ftp://etherscan.io",
+	}
+	err := cfg.Validate(Requirements{RPC: true})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("期望 *ValidationError，得到 %v", err)
+	}
+
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	// HTTP 节点不接受 ws/wss (故障转移传输只支持 http/https)，每个错误字段都应一次性列出
+	want := []string{"INFURA_URL", "INFURA_URL", "INFURA_WS_URL", "PRIVATE_KEY", "TOKEN_LIST", "contracts.counter", "EXPLORER_URL"}
+	if !slices.Equal(fields, want) {
+		t.Errorf("错误字段 = %v, 期望 %v", fields, want)
+	}
+}
+
+func TestValidateRequirements(t *testing.T) {
+	err := (&Config{}).Validate(Requirements{RPC: true, WebSocket: true, PrivateKey: true})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 3 {
+		t.Fatalf("缺少三项必填配置时应返回 3 个错误，得到 %v", err)
+	}
+	if err := (&Config{RPCURLs: []string{"http://localhost:8545"}}).Validate(Requirements{RPC: true}); err != nil {
+		t.Errorf("有效配置不应返回错误，得到 %v", err)
+	}
+}
+
+func TestLoadConfigNamesNetworkFileFields(t *testing.T) {
+	for _, key := range []string{"INFURA_URL", "INFURA_WS_URL", "TOKEN_LIST", "EXPLORER_URL", "CHAIN_ID", "CONFIRMATIONS",
+		"PRIVATE_KEY", "NETWORK", "RPC_CREDITS_PER_SECOND", "RPC_CREDIT_BUDGET", "RPC_CACHE_DIR", "TEST_MISSING_KEY"} {
+		t.Setenv(key, "") // 已存在的变量不会被 .env 覆盖
+	}
+	path := filepath.Join(t.TempDir(), "networks.yaml")
+	data := `default: testnet
+networks:
+  testnet:
+    chain_id: 11155111
+    rpc: ["ws://rpc.example.com", "https://${TEST_MISSING_KEY}.example.com"]
+    ws: ["http://ws.example.com"]
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(LoadOptions{NetworksPath: path, Require: Requirements{RPC: true}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("期望 *ValidationError，得到 %v", err)
+	}
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	// 引用未设置环境变量的地址被跳过，其余错误按配置文件中的字段路径报告
+	want := []string{"networks.testnet.rpc", "networks.testnet.ws"}
+	if !slices.Equal(fields, want) {
+		t.Errorf("错误字段 = %v, 期望 %v", fields, want)
+	}
+}
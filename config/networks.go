@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultNetworksFile 默认的网络配置文件，未找到时在父目录中查找 (以防从 cmd/ 运行)
+const DefaultNetworksFile = "networks.yaml"
+
+// Network 配置文件中的一个命名网络
+type Network struct {
+	Name          string            `yaml:"-"`
+	ChainID       uint64            `yaml:"chain_id"`      // 期望的链 ID
+	RPC           []string          `yaml:"rpc"`           // HTTP 节点，按优先级排列
+	WS            []string          `yaml:"ws"`            // WebSocket 节点，按优先级排列
+	Explorer      string            `yaml:"explorer"`      // 区块浏览器地址，如 https://sepolia.etherscan.io
+	Confirmations uint64            `yaml:"confirmations"` // 等待交易上链时要求的确认数
+	Contracts     map[string]string `yaml:"contracts"`     // 已知合约: 名称 -> 地址
+	Tokens        []string          `yaml:"tokens"`        // account 模式默认查询的 ERC-20 代币
+}
+
+// NetworksFile 网络配置文件的结构
+type NetworksFile struct {
+	Default  string              `yaml:"default"` // 未指定 -network 时使用的网络
+	Networks map[string]*Network `yaml:"networks"`
+}
+
+// LoadNetworksFile 读取网络配置文件
+func LoadNetworksFile(path string) (*NetworksFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file NetworksFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("解析网络配置 %s 失败: %v", path, err)
+	}
+	for name, n := range file.Networks {
+		if n == nil {
+			n = &Network{}
+			file.Networks[name] = n
+		}
+		n.Name = name
+		n.Explorer = strings.TrimRight(strings.TrimSpace(n.Explorer), "/")
+	}
+	return &file, nil
+}
+
+// Network 按名称查找网络；name 为空时使用 default
+func (f *NetworksFile) Network(name string) (*Network, error) {
+	if name == "" {
+		name = f.Default
+	}
+	if name == "" {
+		return nil, nil
+	}
+	n, ok := f.Networks[name]
+	if !ok {
+		return nil, fmt.Errorf("网络配置中没有名为 %q 的网络 (可选: %s)", name, strings.Join(f.Names(), ", "))
+	}
+	return n, nil
+}
+
+// Names 返回按名称排序的网络列表
+func (f *NetworksFile) Names() []string {
+	names := make([]string, 0, len(f.Networks))
+	for name := range f.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectNetwork 加载 path 并选择网络。path 为默认文件名且不存在时返回 nil (仅使用环境变量)；
+// 显式指定的文件或网络不存在时返回错误。
+func selectNetwork(path string, name string, logger *slog.Logger) (*Network, error) {
+	explicit := path != "" && path != DefaultNetworksFile
+	if path == "" {
+		path = DefaultNetworksFile
+	}
+	file, err := LoadNetworksFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit && !filepath.IsAbs(path) {
+		file, err = LoadNetworksFile(filepath.Join("..", path))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		if explicit || name != "" {
+			return nil, fmt.Errorf("未找到网络配置文件 %s", path)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	n, err := file.Network(name)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	// 节点地址中的 ${VAR} 替换为环境变量，因此 API Key 可以留在 .env 中而不写入配置文件
+	n.RPC = expandList(n.RPC, logger)
+	n.WS = expandList(n.WS, logger)
+	return n, nil
+}
+
+// expandList 替换每一项中的环境变量并去掉空项；引用了未设置的环境变量的项会被跳过，
+// 例如未设置 INFURA_API_KEY 时只使用不需要 Key 的公共节点。跳过的项通过 logger 提示。
+func expandList(items []string, logger *slog.Logger) []string {
+	var out []string
+	for _, item := range items {
+		missing := ""
+		item = strings.TrimSpace(os.Expand(item, func(key string) string {
+			v := os.Getenv(key)
+			if v == "" && missing == "" {
+				missing = key
+			}
+			return v
+		}))
+		if missing != "" {
+			logger.Warn("跳过节点地址，引用的环境变量未设置", "var", missing)
+			continue
+		}
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
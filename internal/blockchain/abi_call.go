@@ -0,0 +1,430 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LoadABIFile 读取 ABI 文件。
+// 支持纯 ABI 数组 (solc --abi 输出)，也支持带 "abi" 字段的编译产物 (Hardhat / Foundry)。
+func LoadABIFile(path string) (*abi.ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 ABI 文件失败: %v", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return nil, fmt.Errorf("解析编译产物失败: %v", err)
+		}
+		if len(artifact.ABI) == 0 {
+			return nil, fmt.Errorf("文件 %s 中没有 abi 字段", path)
+		}
+		data = artifact.ABI
+	}
+
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解析 ABI 失败: %v", err)
+	}
+	return &parsed, nil
+}
+
+// FindMethod 按名称或完整签名 (如 "transfer(address,uint256)") 查找方法
+func FindMethod(contractABI *abi.ABI, name string) (*abi.Method, error) {
+	if method, ok := contractABI.Methods[name]; ok {
+		return &method, nil
+	}
+	for _, method := range contractABI.Methods {
+		if method.Sig == name {
+			return &method, nil
+		}
+	}
+	return nil, fmt.Errorf("ABI 中不存在方法 %q", name)
+}
+
+// ParseMethodArgs 按照方法的输入类型将字符串参数转换为 Go 值。
+// 数组和元组参数使用 JSON 表示，如 '[1,2,3]' 或 '["0xabc...", 5]'；
+// 元组也可以使用按字段名的 JSON 对象。
+func ParseMethodArgs(method *abi.Method, args []string) ([]interface{}, error) {
+	if len(args) != len(method.Inputs) {
+		return nil, fmt.Errorf("方法 %s 需要 %d 个参数，实际提供 %d 个", method.Sig, len(method.Inputs), len(args))
+	}
+
+	values := make([]interface{}, len(args))
+	for i, input := range method.Inputs {
+		var raw interface{} = args[i]
+		if input.Type.T == abi.SliceTy || input.Type.T == abi.ArrayTy || input.Type.T == abi.TupleTy {
+			decoder := json.NewDecoder(strings.NewReader(args[i]))
+			decoder.UseNumber()
+			if err := decoder.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("参数 %d (%s) 不是有效的 JSON: %v", i, input.Type.String(), err)
+			}
+			if _, err := decoder.Token(); err != io.EOF {
+				return nil, fmt.Errorf("参数 %d (%s) 不是有效的 JSON: 末尾有多余内容", i, input.Type.String())
+			}
+		}
+		v, err := convertABIValue(input.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("参数 %d (%s %s): %v", i, input.Type.String(), input.Name, err)
+		}
+		values[i] = v.Interface()
+	}
+	return values, nil
+}
+
+// convertABIValue 将字符串 / JSON 值递归转换为 ABI 类型对应的 Go 值
+func convertABIValue(t abi.Type, raw interface{}) (reflect.Value, error) {
+	goType := t.GetType()
+
+	switch t.T {
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("需要数组")
+		}
+		var v reflect.Value
+		if t.T == abi.SliceTy {
+			v = reflect.MakeSlice(goType, len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("需要 %d 个元素，实际 %d 个", t.Size, len(items))
+			}
+			v = reflect.New(goType).Elem()
+		}
+		for i, item := range items {
+			elem, err := convertABIValue(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("元素 %d: %v", i, err)
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+
+	case abi.TupleTy:
+		v := reflect.New(goType).Elem()
+		switch fields := raw.(type) {
+		case []interface{}:
+			if len(fields) != len(t.TupleElems) {
+				return reflect.Value{}, fmt.Errorf("元组需要 %d 个字段，实际 %d 个", len(t.TupleElems), len(fields))
+			}
+			for i, elemType := range t.TupleElems {
+				elem, err := convertABIValue(*elemType, fields[i])
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("字段 %d: %v", i, err)
+				}
+				v.Field(i).Set(elem)
+			}
+		case map[string]interface{}:
+			for i, elemType := range t.TupleElems {
+				name := t.TupleRawNames[i]
+				field, ok := fields[name]
+				if !ok {
+					return reflect.Value{}, fmt.Errorf("缺少字段 %q", name)
+				}
+				elem, err := convertABIValue(*elemType, field)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("字段 %s: %v", name, err)
+				}
+				v.Field(i).Set(elem)
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("元组需要 JSON 数组或对象")
+		}
+		return v, nil
+	}
+
+	// 以下均为标量类型，统一转换为字符串后解析
+	var s string
+	switch x := raw.(type) {
+	case string:
+		s = strings.TrimSpace(x)
+	case json.Number:
+		s = x.String()
+	case bool:
+		s = strconv.FormatBool(x)
+	default:
+		return reflect.Value{}, fmt.Errorf("无法解析值 %v", raw)
+	}
+
+	switch t.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("无效的地址 %q", s)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("无效的布尔值 %q", s)
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.StringTy:
+		return reflect.ValueOf(s), nil
+
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("无效的整数 %q", s)
+		}
+		if t.T == abi.UintTy && n.Sign() < 0 {
+			return reflect.Value{}, fmt.Errorf("无符号整数不能为负数")
+		}
+		bits := n.BitLen()
+		if t.T == abi.IntTy {
+			if n.Sign() < 0 {
+				// 负数的补码表示: -2^(k-1) 需要 k 位
+				bits = new(big.Int).Sub(new(big.Int).Neg(n), big.NewInt(1)).BitLen()
+			}
+			bits++ // 符号位
+		}
+		if bits > t.Size {
+			return reflect.Value{}, fmt.Errorf("%s 超出 %s 的范围", s, t.String())
+		}
+		if goType == reflect.TypeOf(new(big.Int)) {
+			return reflect.ValueOf(n), nil
+		}
+		if t.T == abi.UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(goType), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(goType), nil
+
+	case abi.BytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("无效的十六进制字节 %q: %v", s, err)
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("无效的十六进制字节 %q: %v", s, err)
+		}
+		v := reflect.New(goType).Elem()
+		if len(b) != v.Len() {
+			return reflect.Value{}, fmt.Errorf("需要 %d 字节，实际 %d 字节", v.Len(), len(b))
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("不支持的类型 %s", t.String())
+}
+
+// NormalizeABIValue 将 ABI 解码结果转换为便于打印和 JSON 序列化的形式：
+// 大整数转为十进制字符串，字节转为十六进制，元组转为按字段名的对象。
+func NormalizeABIValue(t abi.Type, v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		if n, ok := v.(*big.Int); ok {
+			return n.String()
+		}
+		return fmt.Sprint(v)
+	case abi.AddressTy:
+		return v.(common.Address).Hex()
+	case abi.BytesTy:
+		return hexutil.Encode(v.([]byte))
+	case abi.FixedBytesTy, abi.FunctionTy, abi.HashTy:
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = NormalizeABIValue(*t.Elem, rv.Index(i).Interface())
+		}
+		return items
+	case abi.TupleTy:
+		fields := make(map[string]interface{}, len(t.TupleElems))
+		for i, elemType := range t.TupleElems {
+			fields[t.TupleRawNames[i]] = NormalizeABIValue(*elemType, rv.Field(i).Interface())
+		}
+		return fields
+	}
+	return v
+}
+
+// MethodOutput 单个返回值，Value 为 NormalizeABIValue 的结果
+type MethodOutput struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// MethodCallRecord 只读调用结果的结构化输出。CSV 列为方法签名加各返回值 (按输出参数名)，
+// 非字符串的返回值 (数组、元组) 以 JSON 写入单元格。
+type MethodCallRecord struct {
+	Method  string         `json:"method"`
+	Outputs []MethodOutput `json:"outputs"`
+}
+
+// NewMethodCallRecord 由方法定义和解码后的返回值构造输出记录
+func NewMethodCallRecord(method *abi.Method, values []interface{}) *MethodCallRecord {
+	r := &MethodCallRecord{Method: method.Sig, Outputs: make([]MethodOutput, len(method.Outputs))}
+	for i, output := range method.Outputs {
+		name := output.Name
+		if name == "" {
+			name = fmt.Sprintf("[%d]", i)
+		}
+		r.Outputs[i] = MethodOutput{Name: name, Type: output.Type.String(), Value: NormalizeABIValue(output.Type, values[i])}
+	}
+	return r
+}
+
+func (r *MethodCallRecord) CSVHeader() []string {
+	header := []string{"method"}
+	for _, o := range r.Outputs {
+		header = append(header, o.Name)
+	}
+	return header
+}
+
+func (r *MethodCallRecord) CSVRow() []string {
+	row := []string{r.Method}
+	for _, o := range r.Outputs {
+		row = append(row, o.String())
+	}
+	return row
+}
+
+func (o MethodOutput) String() string {
+	if s, ok := o.Value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(o.Value)
+	return string(data)
+}
+
+// PrintMethodOutputs 按输出参数名打印解码后的返回值；非 text 格式时输出 MethodCallRecord
+func PrintMethodOutputs(method *abi.Method, values []interface{}) error {
+	if !IsTextOutput() {
+		return Emit(NewMethodCallRecord(method, values))
+	}
+	if len(method.Outputs) == 0 {
+		fmt.Fprintln(TextOut(), "返回值: (无)")
+		return nil
+	}
+	for _, o := range NewMethodCallRecord(method, values).Outputs {
+		fmt.Fprintf(TextOut(), "%s (%s): %s\n", o.Name, o.Type, o)
+	}
+	return nil
+}
+
+// CallContractMethod 通过 eth_call 在指定区块调用任意合约方法并返回解码后的输出
+func CallContractMethod(client Client, contractAddressHex string, contractABI *abi.ABI, methodName string, args []string, block BlockRef) (*abi.Method, []interface{}, error) {
+	if !common.IsHexAddress(contractAddressHex) {
+		return nil, nil, fmt.Errorf("无效的合约地址: %s", contractAddressHex)
+	}
+	method, err := FindMethod(contractABI, methodName)
+	if err != nil {
+		return nil, nil, err
+	}
+	params, err := ParseMethodArgs(method, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bound := bind.NewBoundContract(common.HexToAddress(contractAddressHex), *contractABI, client, client, client)
+	var out []interface{}
+	if err := bound.Call(block.CallOpts(context.Background()), &out, method.Name, params...); err != nil {
+		return nil, nil, fmt.Errorf("调用 %s 失败: %w", method.Sig, wrapStateError(DecodeContractError(err, contractABI), block))
+	}
+	return method, out, nil
+}
+
+// SendContractMethod 签名并发送任意合约方法的交易。
+// value 为随交易发送的 ETH 数量，仅 payable 方法允许非零值。
+func SendContractMethod(client Client, privateKeyHex string, contractAddressHex string, contractABI *abi.ABI, methodName string, args []string, value *big.Int) (*types.Transaction, error) {
+	if !common.IsHexAddress(contractAddressHex) {
+		return nil, fmt.Errorf("无效的合约地址: %s", contractAddressHex)
+	}
+	method, err := FindMethod(contractABI, methodName)
+	if err != nil {
+		return nil, err
+	}
+	if value != nil && value.Sign() > 0 && !method.Payable {
+		return nil, fmt.Errorf("方法 %s 不是 payable，不能附带 ETH", method.Sig)
+	}
+	if method.IsConstant() {
+		fmt.Fprintf(TextOut(), "提示: %s 是只读方法，发送交易不会改变状态，可改用 -mode call\n", method.Sig)
+	}
+	params, err := ParseMethodArgs(method, args)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := getTransactOpts(client, privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	auth.GasLimit = 0 // 由 BoundContract 自动估算
+	if value != nil {
+		auth.Value = value
+	}
+
+	bound := bind.NewBoundContract(common.HexToAddress(contractAddressHex), *contractABI, client, retrySendClient{client}, client)
+	tx, err := bound.Transact(auth, method.Name, params...)
+	if err != nil {
+		return nil, fmt.Errorf("发送 %s 交易失败: %w", method.Sig, DecodeContractError(err, contractABI))
+	}
+	return tx, nil
+}
+
+// SimulateContractMethod 模拟任意合约方法的交易 (Dry-run)
+func SimulateContractMethod(client Client, privateKeyHex string, contractAddressHex string, contractABI *abi.ABI, methodName string, args []string, value *big.Int) error {
+	_, fromAddress, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return err
+	}
+	if !common.IsHexAddress(contractAddressHex) {
+		return fmt.Errorf("无效的合约地址: %s", contractAddressHex)
+	}
+	method, err := FindMethod(contractABI, methodName)
+	if err != nil {
+		return err
+	}
+	params, err := ParseMethodArgs(method, args)
+	if err != nil {
+		return err
+	}
+	data, err := contractABI.Pack(method.Name, params...)
+	if err != nil {
+		return fmt.Errorf("编码调用数据失败: %v", err)
+	}
+
+	contractAddress := common.HexToAddress(contractAddressHex)
+	result, err := SimulateTx(context.Background(), client, ethereum.CallMsg{
+		From:  fromAddress,
+		To:    &contractAddress,
+		Value: value,
+		Data:  data,
+	}, contractABI)
+	if err != nil {
+		return err
+	}
+	PrintSimulation(result, method)
+	return simulationError(result)
+}
@@ -0,0 +1,142 @@
+package blockchain
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testMethodABI = `[
+	{"type":"function","name":"scalars","stateMutability":"nonpayable","inputs":[
+		{"name":"to","type":"address"},
+		{"name":"small","type":"uint8"},
+		{"name":"signed","type":"int8"},
+		{"name":"amount","type":"uint256"},
+		{"name":"flag","type":"bool"},
+		{"name":"id","type":"bytes32"},
+		{"name":"data","type":"bytes"},
+		{"name":"note","type":"string"}
+	],"outputs":[]},
+	{"type":"function","name":"composite","stateMutability":"nonpayable","inputs":[
+		{"name":"ids","type":"uint256[]"},
+		{"name":"pair","type":"address[2]"},
+		{"name":"order","type":"tuple","components":[{"name":"maker","type":"address"},{"name":"amount","type":"uint64"}]}
+	],"outputs":[]}
+]`
+
+func mustMethod(t *testing.T, name string) *abi.Method {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(testMethodABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, err := FindMethod(&parsed, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return method
+}
+
+func TestParseMethodArgsScalars(t *testing.T) {
+	method := mustMethod(t, "scalars")
+	addr := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	id := "0x" + strings.Repeat("ab", 32)
+	valid := []string{addr, "255", "-128", "0x10", "true", id, "0x0102", " hello "}
+
+	values, err := ParseMethodArgs(method, valid)
+	if err != nil {
+		t.Fatalf("ParseMethodArgs 返回错误: %v", err)
+	}
+	want := []interface{}{
+		common.HexToAddress(addr), uint8(255), int8(-128), big.NewInt(16), true,
+		[32]byte(common.HexToHash(id)), []byte{1, 2}, "hello",
+	}
+	for i := range want {
+		if !reflect.DeepEqual(values[i], want[i]) {
+			t.Errorf("参数 %d = %#v, 期望 %#v", i, values[i], want[i])
+		}
+	}
+
+	tests := []struct {
+		name    string
+		index   int
+		value   string
+		wantErr string
+	}{
+		{name: "uint8 溢出", index: 1, value: "256", wantErr: "超出"},
+		{name: "uint8 负数", index: 1, value: "-1", wantErr: "不能为负数"},
+		{name: "int8 下溢", index: 2, value: "-129", wantErr: "超出"},
+		{name: "int8 上溢", index: 2, value: "128", wantErr: "超出"},
+		{name: "无效整数", index: 3, value: "1.5", wantErr: "无效的整数"},
+		{name: "无效地址", index: 0, value: "0x1234", wantErr: "无效的地址"},
+		{name: "无效布尔值", index: 4, value: "yes", wantErr: "无效的布尔值"},
+		{name: "bytes32 长度错误", index: 5, value: "0xabcd", wantErr: "需要 32 字节"},
+		{name: "bytes 非十六进制", index: 6, value: "0xzz", wantErr: "无效的十六进制"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string(nil), valid...)
+			args[tt.index] = tt.value
+			_, err := ParseMethodArgs(method, args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("期望包含 %q 的错误，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := ParseMethodArgs(method, valid[:2]); err == nil || !strings.Contains(err.Error(), "需要 8 个参数") {
+		t.Errorf("参数个数错误时应报错，得到 %v", err)
+	}
+}
+
+func TestParseMethodArgsComposite(t *testing.T) {
+	method := mustMethod(t, "composite")
+	a := "0x1000000000000000000000000000000000000001"
+	b := "0x2000000000000000000000000000000000000002"
+
+	for _, order := range []string{
+		`["` + a + `", 42]`,
+		`{"maker": "` + a + `", "amount": "42"}`,
+	} {
+		values, err := ParseMethodArgs(method, []string{`[1, "2", 340282366920938463463374607431768211456]`, `["` + a + `","` + b + `"]`, order})
+		if err != nil {
+			t.Fatalf("ParseMethodArgs(%s) 返回错误: %v", order, err)
+		}
+		ids := values[0].([]*big.Int)
+		if len(ids) != 3 || ids[0].Int64() != 1 || ids[1].Int64() != 2 || ids[2].String() != "340282366920938463463374607431768211456" {
+			t.Errorf("ids = %v", ids)
+		}
+		if pair := values[1].([2]common.Address); pair[1] != common.HexToAddress(b) {
+			t.Errorf("pair = %v", pair)
+		}
+		tuple := reflect.ValueOf(values[2])
+		if tuple.Field(0).Interface() != common.HexToAddress(a) || tuple.Field(1).Uint() != 42 {
+			t.Errorf("order = %+v", values[2])
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "数组不是 JSON", args: []string{`[1,2`, `["` + a + `","` + b + `"]`, `["` + a + `", 1]`}, wantErr: "不是有效的 JSON"},
+		{name: "JSON 末尾有多余内容", args: []string{`[1,2] [3]`, `["` + a + `","` + b + `"]`, `["` + a + `", 1]`}, wantErr: "不是有效的 JSON"},
+		{name: "定长数组长度错误", args: []string{`[]`, `["` + a + `"]`, `["` + a + `", 1]`}, wantErr: "需要 2 个元素"},
+		{name: "元组字段缺失", args: []string{`[]`, `["` + a + `","` + b + `"]`, `{"maker": "` + a + `"}`}, wantErr: `缺少字段 "amount"`},
+		{name: "元组字段个数错误", args: []string{`[]`, `["` + a + `","` + b + `"]`, `["` + a + `"]`}, wantErr: "元组需要 2 个字段"},
+		{name: "元素类型错误", args: []string{`[1, "x"]`, `["` + a + `","` + b + `"]`, `["` + a + `", 1]`}, wantErr: "元素 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMethodArgs(method, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("期望包含 %q 的错误，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
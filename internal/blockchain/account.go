@@ -0,0 +1,186 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// StorageSlot 一个存储槽及其原始值
+type StorageSlot struct {
+	Slot  string `json:"slot"`
+	Value string `json:"value"` // 32 字节十六进制
+	Uint  string `json:"uint"`  // 按 uint256 解读的十进制值
+}
+
+// TokenBalance 账户持有的一种 ERC-20 代币
+type TokenBalance struct {
+	Token     string `json:"token"`
+	Symbol    string `json:"symbol,omitempty"`
+	Decimals  uint8  `json:"decimals"`
+	Balance   string `json:"balance,omitempty"`   // 最小单位
+	Formatted string `json:"formatted,omitempty"` // 按 decimals 换算后的数量
+	Error     string `json:"error,omitempty"`
+}
+
+// AccountInfo 账户在某一区块的状态
+type AccountInfo struct {
+	Address    string         `json:"address"`
+	Block      uint64         `json:"block"`
+	Balance    string         `json:"balance"` // wei
+	Nonce      uint64         `json:"nonce"`
+	IsContract bool           `json:"isContract"`
+	CodeSize   int            `json:"codeSize"`
+	CodeHash   string         `json:"codeHash,omitempty"`
+	Storage    []StorageSlot  `json:"storage,omitempty"`
+	Tokens     []TokenBalance `json:"tokens,omitempty"`
+}
+
+func (a *AccountInfo) CSVHeader() []string {
+	return []string{"address", "block", "balance", "nonce", "is_contract", "code_size", "code_hash"}
+}
+
+func (a *AccountInfo) CSVRow() []string {
+	return []string{a.Address, u64(a.Block), a.Balance, u64(a.Nonce), strconv.FormatBool(a.IsContract), strconv.Itoa(a.CodeSize), a.CodeHash}
+}
+
+// ParseStorageSlot 解析存储槽：十进制或 0x 十六进制的槽号，或 32 字节的槽位键 (如 mapping 计算结果)
+func ParseStorageSlot(s string) (common.Hash, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") && len(s) == 2+2*common.HashLength {
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("无效的存储槽: %s", s)
+		}
+		return common.BytesToHash(b), nil
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return common.Hash{}, fmt.Errorf("无效的存储槽: %q", s)
+	}
+	return common.BigToHash(n), nil
+}
+
+// GetAccountInfo 读取账户在指定区块的余额、nonce、代码、存储槽和 ERC-20 余额。
+// 标签和哈希会先解析为具体区块号，所有读取都固定在同一区块。
+func GetAccountInfo(ctx context.Context, client Client, account common.Address, ref BlockRef, slots []common.Hash, tokens []common.Address) (*AccountInfo, error) {
+	number, err := ResolveBlockNumber(ctx, client, ref)
+	if err != nil {
+		return nil, err
+	}
+	pinned := BlockRef{Number: number}
+	info := &AccountInfo{Address: account.Hex(), Block: number.Uint64()}
+
+	balance, err := GetBalanceAt(ctx, client, account, pinned)
+	if err != nil {
+		return nil, err
+	}
+	info.Balance = balance.String()
+
+	if info.Nonce, err = client.NonceAt(ctx, account, number); err != nil {
+		return nil, fmt.Errorf("读取 nonce 失败: %w", wrapStateError(err, pinned))
+	}
+
+	code, err := client.CodeAt(ctx, account, number)
+	if err != nil {
+		return nil, fmt.Errorf("读取合约代码失败: %w", wrapStateError(err, pinned))
+	}
+	info.CodeSize = len(code)
+	info.IsContract = len(code) > 0
+	if info.IsContract {
+		info.CodeHash = crypto.Keccak256Hash(code).Hex()
+	}
+
+	for _, slot := range slots {
+		value, err := client.StorageAt(ctx, account, slot, number)
+		if err != nil {
+			return nil, fmt.Errorf("读取存储槽 %s 失败: %w", slot.Hex(), wrapStateError(err, pinned))
+		}
+		info.Storage = append(info.Storage, StorageSlot{
+			Slot:  slot.Hex(),
+			Value: common.BytesToHash(value).Hex(),
+			Uint:  new(big.Int).SetBytes(value).String(),
+		})
+	}
+
+	if len(tokens) > 0 {
+		if info.Tokens, err = getTokenBalances(ctx, client, account, tokens, number); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// getTokenBalances 通过 Multicall3 在同一区块读取多个代币余额；单个代币失败不影响其他代币
+func getTokenBalances(ctx context.Context, client Client, account common.Address, tokens []common.Address, number *big.Int) ([]TokenBalance, error) {
+	owners := make([]common.Address, len(tokens))
+	for i := range owners {
+		owners[i] = account
+	}
+	balances, errs, err := GetERC20Balances(ctx, client, tokens, owners, number)
+	if err != nil {
+		return nil, fmt.Errorf("批量读取代币余额失败: %v", err)
+	}
+
+	result := make([]TokenBalance, len(tokens))
+	for i, token := range tokens {
+		result[i].Token = token.Hex()
+		if errs[i] != nil {
+			result[i].Error = errs[i].Error()
+			continue
+		}
+		result[i].Balance = balances[i].String()
+		tokenInfo, err := GetERC20Info(ctx, client, token)
+		if err != nil {
+			result[i].Error = err.Error()
+			continue
+		}
+		result[i].Symbol = tokenInfo.Symbol
+		result[i].Decimals = tokenInfo.Decimals
+		result[i].Formatted = FormatUnits(balances[i], tokenInfo.Decimals)
+	}
+	return result, nil
+}
+
+// PrintAccountInfo 打印账户信息；非 text 格式时输出 AccountInfo 记录
+func PrintAccountInfo(info *AccountInfo) error {
+	if !IsTextOutput() {
+		return Emit(info)
+	}
+
+	fmt.Fprintln(TextOut(), "==================================================")
+	fmt.Fprintf(TextOut(), "地址:       %s\n", info.Address)
+	fmt.Fprintf(TextOut(), "区块:       %d\n", info.Block)
+	fmt.Fprintf(TextOut(), "ETH 余额:   %s ETH\n", formatWeiString(info.Balance, FormatEther))
+	fmt.Fprintf(TextOut(), "Nonce:      %d\n", info.Nonce)
+	if info.IsContract {
+		fmt.Fprintf(TextOut(), "类型:       合约 (代码 %d 字节)\n", info.CodeSize)
+		fmt.Fprintf(TextOut(), "代码哈希:   %s\n", info.CodeHash)
+	} else {
+		fmt.Fprintln(TextOut(), "类型:       外部账户 (EOA)")
+	}
+	if len(info.Storage) > 0 {
+		fmt.Fprintln(TextOut(), "存储槽:")
+		for _, s := range info.Storage {
+			fmt.Fprintf(TextOut(), "  %s = %s (uint256: %s)\n", s.Slot, s.Value, s.Uint)
+		}
+	}
+	if len(info.Tokens) > 0 {
+		fmt.Fprintln(TextOut(), "代币余额:")
+		for _, t := range info.Tokens {
+			if t.Error != "" {
+				fmt.Fprintf(TextOut(), "  %s: 读取失败 (%s)\n", t.Token, t.Error)
+				continue
+			}
+			fmt.Fprintf(TextOut(), "  %s %s (%s)\n", t.Formatted, t.Symbol, t.Token)
+		}
+	}
+	fmt.Fprintln(TextOut(), "==================================================")
+	return nil
+}
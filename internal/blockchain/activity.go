@@ -0,0 +1,399 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ActivityRecord 与被监控地址相关的一笔交易或一条日志
+type ActivityRecord struct {
+	Block     uint64 `json:"block"`
+	Timestamp uint64 `json:"timestamp"`
+	Kind      string `json:"kind"` // tx 或 log
+	TxHash    string `json:"txHash"`
+	TxIndex   uint   `json:"txIndex"`
+	LogIndex  *uint  `json:"logIndex,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Value     string `json:"value,omitempty"`    // wei
+	Selector  string `json:"selector,omitempty"` // 交易调用数据的函数选择器
+	Emitter   string `json:"emitter,omitempty"`  // 日志的合约地址
+	Topic0    string `json:"topic0,omitempty"`
+	Matched   string `json:"matched"` // 命中的被监控地址
+	Roles     string `json:"roles"`   // 命中位置，如 from、to、emitter、topic1，多个以 ; 分隔
+}
+
+func (r *ActivityRecord) CSVHeader() []string {
+	return []string{"block", "timestamp", "kind", "tx_hash", "tx_index", "log_index", "from", "to", "value", "selector", "emitter", "topic0", "matched", "roles"}
+}
+
+func (r *ActivityRecord) CSVRow() []string {
+	logIndex := ""
+	if r.LogIndex != nil {
+		logIndex = strconv.FormatUint(uint64(*r.LogIndex), 10)
+	}
+	return []string{u64(r.Block), u64(r.Timestamp), r.Kind, r.TxHash, strconv.FormatUint(uint64(r.TxIndex), 10), logIndex,
+		r.From, r.To, r.Value, r.Selector, r.Emitter, r.Topic0, r.Matched, r.Roles}
+}
+
+// ActivityScanOptions 地址活动扫描选项
+type ActivityScanOptions struct {
+	Addresses  []common.Address
+	StartBlock uint64 // 必填，避免误从创世区块开始扫描整条链
+	EndBlock   uint64 // 0 表示最新区块
+	OutputPath string // .csv 输出 CSV，其他扩展名输出 NDJSON
+}
+
+// activityProgress 断点文件，记录扫描参数、已完整处理的最后一个区块以及此时输出文件的长度
+type activityProgress struct {
+	Addresses  []string `json:"addresses"`
+	StartBlock uint64   `json:"startBlock"`
+	EndBlock   uint64   `json:"endBlock"` // 0 表示最新区块 (与命令行参数一致，不是解析后的区块号)
+	LastBlock  uint64   `json:"lastBlock"`
+	Offset     int64    `json:"offset"`
+}
+
+// ScanAddressActivity 基于 ScanBlocks 扫描区块范围，收集与指定地址相关的交易 (from / to)
+// 以及合约地址或 topics 中包含这些地址的日志，逐区块追加写入输出文件。
+//
+// 每处理完一个区块都会更新 <输出文件>.progress。再次运行时若断点文件存在且地址列表与区块范围一致，
+// 会先把输出文件截断到断点时的长度 (丢弃未完成区块的部分写入)，再从下一个区块继续；不一致时拒绝续扫。
+func ScanAddressActivity(ctx context.Context, client Client, opts ActivityScanOptions) (int, error) {
+	if len(opts.Addresses) == 0 {
+		return 0, fmt.Errorf("请至少指定一个地址")
+	}
+	if opts.StartBlock == 0 {
+		return 0, fmt.Errorf("请通过 -start-block 指定起始区块 (从创世区块扫描整条链的代价过高)")
+	}
+	requestedEnd := opts.EndBlock
+	if opts.EndBlock == 0 {
+		latest, err := client.BlockNumber(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("获取最新区块号失败: %v", err)
+		}
+		opts.EndBlock = latest
+	}
+	if opts.StartBlock > opts.EndBlock {
+		return 0, fmt.Errorf("起始区块 %d 大于结束区块 %d", opts.StartBlock, opts.EndBlock)
+	}
+
+	watched := make(map[common.Address]bool, len(opts.Addresses))
+	var addrList []string
+	for _, a := range opts.Addresses {
+		watched[a] = true
+		addrList = append(addrList, a.Hex())
+	}
+	slices.Sort(addrList)
+
+	progressPath := opts.OutputPath + ".progress"
+	progress, err := loadActivityProgress(progressPath)
+	if err != nil {
+		return 0, err
+	}
+	start := opts.StartBlock
+	var offset int64
+	if progress != nil {
+		if !slices.Equal(progress.Addresses, addrList) {
+			return 0, fmt.Errorf("断点文件 %s 的地址列表与本次不同，请更换输出文件或删除断点文件", progressPath)
+		}
+		if progress.StartBlock != opts.StartBlock || progress.EndBlock != requestedEnd {
+			return 0, fmt.Errorf("断点文件 %s 的区块范围 (%d - %s) 与本次 (%d - %s) 不同，请更换输出文件或删除断点文件",
+				progressPath, progress.StartBlock, formatEndBlock(progress.EndBlock), opts.StartBlock, formatEndBlock(requestedEnd))
+		}
+		start = progress.LastBlock + 1
+		offset = progress.Offset
+		Logger().Info("从断点继续扫描", logBlock(progress.LastBlock), "file", opts.OutputPath, "offset", offset)
+	}
+	if start > opts.EndBlock {
+		Logger().Info("区块范围已全部扫描完成", "start", opts.StartBlock, "end", opts.EndBlock)
+		return 0, nil
+	}
+
+	w, err := openActivityWriter(opts.OutputPath, offset)
+	if err != nil {
+		return 0, err
+	}
+	defer w.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+
+	scanCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	found := 0
+	onBlock := func(header *types.Header) {
+		if scanCtx.Err() != nil {
+			return
+		}
+		number := header.Number.Uint64()
+		records, err := collectBlockActivity(scanCtx, client, header, signer, watched)
+		if err != nil {
+			cancel(err)
+			return
+		}
+		for _, r := range records {
+			if err := w.Write(r); err != nil {
+				cancel(err)
+				return
+			}
+		}
+		size, err := w.Flush()
+		if err != nil {
+			cancel(err)
+			return
+		}
+		found += len(records)
+		if err := saveActivityProgress(progressPath, &activityProgress{
+			Addresses:  addrList,
+			StartBlock: opts.StartBlock,
+			EndBlock:   requestedEnd,
+			LastBlock:  number,
+			Offset:     size,
+		}); err != nil {
+			cancel(err)
+			return
+		}
+		if len(records) > 0 {
+			Logger().Info("发现相关记录", logBlock(number), "records", len(records))
+		}
+	}
+
+	// NeverSkip: 区块获取失败时停止扫描而不是跳过，保证断点之前没有遗漏
+	err = ScanBlocksWith(scanCtx, client, int64(start), int64(opts.EndBlock), ScanOptions{Retry: DefaultRetryPolicy, NeverSkip: true}, onBlock)
+	if cause := context.Cause(scanCtx); cause != nil && !errors.Is(cause, context.Canceled) {
+		return found, cause
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return found, err
+		}
+		return found, fmt.Errorf("%w，请重新运行以从断点继续", err)
+	}
+	return found, nil
+}
+
+// collectBlockActivity 获取完整区块并找出与被监控地址相关的交易和日志
+func collectBlockActivity(ctx context.Context, client Client, header *types.Header, signer types.Signer, watched map[common.Address]bool) ([]*ActivityRecord, error) {
+	block, err := retryValue(ctx, DefaultRetryPolicy.RetryNotFound(), "获取区块", func() (*types.Block, error) {
+		return client.BlockByHash(ctx, header.Hash())
+	}, logBlock(header.Number.Uint64()))
+	if err != nil {
+		return nil, fmt.Errorf("获取区块 %d 失败: %v", header.Number, err)
+	}
+
+	var records []*ActivityRecord
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, fmt.Errorf("恢复交易 %s 发送方失败: %v", tx.Hash().Hex(), err)
+		}
+		var matched, roles []string
+		if watched[from] {
+			matched, roles = append(matched, from.Hex()), append(roles, "from")
+		}
+		if to := tx.To(); to != nil && watched[*to] {
+			if *to != from {
+				matched = append(matched, to.Hex())
+			}
+			roles = append(roles, "to")
+		}
+		if len(roles) == 0 {
+			continue
+		}
+		r := &ActivityRecord{
+			Block:     header.Number.Uint64(),
+			Timestamp: header.Time,
+			Kind:      "tx",
+			TxHash:    tx.Hash().Hex(),
+			TxIndex:   uint(i),
+			From:      from.Hex(),
+			Value:     tx.Value().String(),
+			Matched:   strings.Join(matched, ";"),
+			Roles:     strings.Join(roles, ";"),
+		}
+		if tx.To() != nil {
+			r.To = tx.To().Hex()
+		}
+		if len(tx.Data()) > 0 {
+			r.Selector = inputSelector(tx.Data())
+		}
+		records = append(records, r)
+	}
+
+	// 先用区块的布隆过滤器判断，只有可能命中时才拉取日志
+	if !bloomMayContain(header.Bloom, watched) {
+		return records, nil
+	}
+	hash := header.Hash()
+	logs, err := retryValue(ctx, DefaultRetryPolicy, "获取区块日志", func() ([]types.Log, error) {
+		return client.FilterLogs(ctx, ethereum.FilterQuery{BlockHash: &hash})
+	}, logBlock(header.Number.Uint64()))
+	if err != nil {
+		return nil, fmt.Errorf("获取区块 %d 日志失败: %v", header.Number, err)
+	}
+	for _, l := range logs {
+		var matched, roles []string
+		if watched[l.Address] {
+			matched, roles = append(matched, l.Address.Hex()), append(roles, "emitter")
+		}
+		for i, topic := range l.Topics {
+			if addr, ok := topicAddress(topic); ok && watched[addr] {
+				if !slices.Contains(matched, addr.Hex()) {
+					matched = append(matched, addr.Hex())
+				}
+				roles = append(roles, "topic"+strconv.Itoa(i))
+			}
+		}
+		if len(roles) == 0 {
+			continue
+		}
+		logIndex := l.Index
+		r := &ActivityRecord{
+			Block:     l.BlockNumber,
+			Timestamp: header.Time,
+			Kind:      "log",
+			TxHash:    l.TxHash.Hex(),
+			TxIndex:   l.TxIndex,
+			LogIndex:  &logIndex,
+			Emitter:   l.Address.Hex(),
+			Matched:   strings.Join(matched, ";"),
+			Roles:     strings.Join(roles, ";"),
+		}
+		if len(l.Topics) > 0 {
+			r.Topic0 = l.Topics[0].Hex()
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// bloomMayContain 布隆过滤器中是否可能包含任一地址 (作为合约地址或左补零的 topic)
+func bloomMayContain(bloom types.Bloom, watched map[common.Address]bool) bool {
+	for addr := range watched {
+		if bloom.Test(addr.Bytes()) || bloom.Test(common.BytesToHash(addr.Bytes()).Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// topicAddress 判断 topic 是否为左补零的地址
+func topicAddress(topic common.Hash) (common.Address, bool) {
+	for _, b := range topic[:common.HashLength-common.AddressLength] {
+		if b != 0 {
+			return common.Address{}, false
+		}
+	}
+	return common.BytesToAddress(topic.Bytes()), true
+}
+
+// formatEndBlock 打印结束区块参数，0 表示最新区块
+func formatEndBlock(n uint64) string {
+	if n == 0 {
+		return "latest"
+	}
+	return u64(n)
+}
+
+func loadActivityProgress(path string) (*activityProgress, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取断点文件失败: %v", err)
+	}
+	var p activityProgress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("解析断点文件 %s 失败: %v", path, err)
+	}
+	return &p, nil
+}
+
+func saveActivityProgress(path string, p *activityProgress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入断点文件失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// activityWriter 以追加方式写入 CSV 或 NDJSON
+type activityWriter struct {
+	f   *os.File
+	csv *csv.Writer
+	enc *json.Encoder
+}
+
+// openActivityWriter 打开输出文件并截断到 offset；新文件为 CSV 时写入表头
+func openActivityWriter(path string, offset int64) (*activityWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开输出文件失败: %v", err)
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("截断输出文件失败: %v", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("定位输出文件失败: %v", err)
+	}
+
+	w := &activityWriter{f: f}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		w.csv = csv.NewWriter(f)
+		if offset == 0 {
+			_ = w.csv.Write((&ActivityRecord{}).CSVHeader())
+		}
+	} else {
+		w.enc = json.NewEncoder(f)
+	}
+	return w, nil
+}
+
+func (w *activityWriter) Write(r *ActivityRecord) error {
+	if w.csv != nil {
+		return w.csv.Write(r.CSVRow())
+	}
+	return w.enc.Encode(r)
+}
+
+// Flush 将缓冲写入磁盘，返回当前文件长度
+func (w *activityWriter) Flush() (int64, error) {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return 0, fmt.Errorf("写入输出文件失败: %v", err)
+		}
+	}
+	if err := w.f.Sync(); err != nil {
+		return 0, fmt.Errorf("写入输出文件失败: %v", err)
+	}
+	return w.f.Seek(0, io.SeekCurrent)
+}
+
+func (w *activityWriter) Close() error {
+	return w.f.Close()
+}
@@ -0,0 +1,43 @@
+package blockchain
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestScanAddressActivityRejectsMismatchedProgress(t *testing.T) {
+	addr := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	out := filepath.Join(t.TempDir(), "activity.csv")
+	if err := saveActivityProgress(out+".progress", &activityProgress{
+		Addresses:  []string{addr.Hex()},
+		StartBlock: 100,
+		EndBlock:   200,
+		LastBlock:  150,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts ActivityScanOptions
+		want string
+	}{
+		{"缺少起始区块", ActivityScanOptions{Addresses: []common.Address{addr}, EndBlock: 200}, "-start-block"},
+		{"起始区块大于结束区块", ActivityScanOptions{Addresses: []common.Address{addr}, StartBlock: 300, EndBlock: 200}, "大于结束区块"},
+		{"起始区块不同", ActivityScanOptions{Addresses: []common.Address{addr}, StartBlock: 90, EndBlock: 200}, "区块范围"},
+		{"结束区块不同", ActivityScanOptions{Addresses: []common.Address{addr}, StartBlock: 100, EndBlock: 250}, "区块范围"},
+		{"地址列表不同", ActivityScanOptions{Addresses: []common.Address{{1}}, StartBlock: 100, EndBlock: 200}, "地址列表"},
+	}
+	for _, tt := range tests {
+		tt.opts.OutputPath = out
+		// 参数校验在访问节点之前完成，因此不需要 client
+		_, err := ScanAddressActivity(context.Background(), nil, tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: 期望包含 %q 的错误，得到 %v", tt.name, tt.want, err)
+		}
+	}
+}
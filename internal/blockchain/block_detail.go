@@ -0,0 +1,180 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// txTypeNames 交易类型名称
+var txTypeNames = map[uint8]string{
+	types.LegacyTxType:     "Legacy",
+	types.AccessListTxType: "AccessList (EIP-2930)",
+	types.DynamicFeeTxType: "DynamicFee (EIP-1559)",
+	types.BlobTxType:       "Blob (EIP-4844)",
+	types.SetCodeTxType:    "SetCode (EIP-7702)",
+}
+
+// TxTypeName 返回交易类型的可读名称
+func TxTypeName(t uint8) string {
+	if name, ok := txTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (0x%x)", t)
+}
+
+// inputSelector 返回调用数据的函数选择器，普通转账返回 "-"
+func inputSelector(data []byte) string {
+	if len(data) < 4 {
+		if len(data) == 0 {
+			return "-"
+		}
+		return hexutil.Encode(data)
+	}
+	return hexutil.Encode(data[:4])
+}
+
+// QueryBlockDetail 查询并打印区块的详细信息，包括每笔交易。
+// withReceipts 为 true 时额外通过 eth_getBlockReceipts 获取回执，打印执行状态、实际 Gas 和日志数量。
+func QueryBlockDetail(client Client, blockNumber int64, withReceipts bool) error {
+	ctx := context.Background()
+
+	block, err := client.BlockByNumber(ctx, big.NewInt(blockNumber))
+	if err != nil {
+		return fmt.Errorf("获取区块失败: %v", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+
+	var receipts []*types.Receipt
+	if withReceipts {
+		receipts, err = blockReceipts(ctx, client, block)
+		if err != nil {
+			return fmt.Errorf("获取区块回执失败: %v", err)
+		}
+		if len(receipts) != len(block.Transactions()) {
+			return fmt.Errorf("回执数量 (%d) 与交易数量 (%d) 不一致", len(receipts), len(block.Transactions()))
+		}
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	if !IsTextOutput() {
+		return emitBlockDetail(block, signer, receipts)
+	}
+
+	printBlockSummary(block)
+
+	for i, tx := range block.Transactions() {
+		fmt.Fprintf(TextOut(), "[%d] %s\n", i, tx.Hash().Hex())
+		fmt.Fprintf(TextOut(), "    类型:       %s\n", TxTypeName(tx.Type()))
+		if from, err := types.Sender(signer, tx); err == nil {
+			fmt.Fprintf(TextOut(), "    发送方:     %s\n", from.Hex())
+		}
+		if tx.To() != nil {
+			fmt.Fprintf(TextOut(), "    接收方:     %s\n", tx.To().Hex())
+		} else {
+			fmt.Fprintln(TextOut(), "    接收方:     (合约创建)")
+		}
+		fmt.Fprintf(TextOut(), "    金额:       %s ETH\n", FormatEther(tx.Value()))
+		if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+			fmt.Fprintf(TextOut(), "    Gas 价格:   %s Gwei\n", FormatGwei(tx.GasPrice()))
+		} else {
+			fmt.Fprintf(TextOut(), "    费用:       优先费上限 %s Gwei / 费用上限 %s Gwei\n", FormatGwei(tx.GasTipCap()), FormatGwei(tx.GasFeeCap()))
+		}
+		fmt.Fprintf(TextOut(), "    Gas 限制:   %d\n", tx.Gas())
+		fmt.Fprintf(TextOut(), "    函数选择器: %s\n", inputSelector(tx.Data()))
+		if tx.Type() == types.BlobTxType {
+			fmt.Fprintf(TextOut(), "    Blob 数量:  %d\n", len(tx.BlobHashes()))
+		}
+
+		if receipts != nil {
+			r := receipts[i]
+			status := "成功"
+			if r.Status != types.ReceiptStatusSuccessful {
+				status = "失败"
+			}
+			fmt.Fprintf(TextOut(), "    执行状态:   %s\n", status)
+			fmt.Fprintf(TextOut(), "    实际 Gas:   %d (%.1f%%)\n", r.GasUsed, percent(r.GasUsed, tx.Gas()))
+			if r.EffectiveGasPrice != nil {
+				fee := new(big.Int).Mul(r.EffectiveGasPrice, new(big.Int).SetUint64(r.GasUsed))
+				fmt.Fprintf(TextOut(), "    实际费用:   %s ETH (有效 Gas 价格 %s Gwei)\n", FormatEther(fee), FormatGwei(r.EffectiveGasPrice))
+			}
+			fmt.Fprintf(TextOut(), "    日志数量:   %d\n", len(r.Logs))
+			if tx.To() == nil {
+				fmt.Fprintf(TextOut(), "    合约地址:   %s\n", r.ContractAddress.Hex())
+			}
+		}
+	}
+	fmt.Fprintln(TextOut(), "--------------------------------------------------")
+	return nil
+}
+
+// printBlockSummary 打印区块级别的汇总信息
+func printBlockSummary(block *types.Block) {
+	fmt.Fprintln(TextOut(), "==================================================")
+	fmt.Fprintf(TextOut(), "区块号:     %d\n", block.NumberU64())
+	fmt.Fprintf(TextOut(), "区块哈希:   %s\n", block.Hash().Hex())
+	fmt.Fprintf(TextOut(), "父哈希:     %s\n", block.ParentHash().Hex())
+	fmt.Fprintf(TextOut(), "时间戳:     %s\n", time.Unix(int64(block.Time()), 0))
+	fmt.Fprintf(TextOut(), "出块地址:   %s\n", block.Coinbase().Hex())
+	fmt.Fprintf(TextOut(), "交易数量:   %d\n", len(block.Transactions()))
+	fmt.Fprintf(TextOut(), "Gas 使用:   %d / %d (%.2f%%)\n", block.GasUsed(), block.GasLimit(), percent(block.GasUsed(), block.GasLimit()))
+	if baseFee := block.BaseFee(); baseFee != nil {
+		fmt.Fprintf(TextOut(), "基础费用:   %s Gwei\n", FormatGwei(baseFee))
+		burnt := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(block.GasUsed()))
+		fmt.Fprintf(TextOut(), "销毁费用:   %s ETH\n", FormatEther(burnt))
+	}
+	if blobGasUsed := block.BlobGasUsed(); blobGasUsed != nil {
+		fmt.Fprintf(TextOut(), "Blob Gas:   已用 %d (%d 个 blob)", *blobGasUsed, *blobGasUsed/params.BlobTxBlobGasPerBlob)
+		if excess := block.ExcessBlobGas(); excess != nil {
+			fmt.Fprintf(TextOut(), ", 超额 %d", *excess)
+		}
+		fmt.Fprintln(TextOut())
+	}
+	if withdrawals := block.Withdrawals(); withdrawals != nil {
+		fmt.Fprintf(TextOut(), "提款:       %d 笔, 共 %s ETH\n", len(withdrawals), FormatEther(withdrawalTotal(withdrawals)))
+	}
+	fmt.Fprintf(TextOut(), "区块大小:   %d 字节\n", block.Size())
+	fmt.Fprintln(TextOut(), "==================================================")
+}
+
+func percent(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
+
+// emitBlockDetail 以结构化格式输出区块详情。CSV 格式逐笔输出交易行，其他格式输出带交易列表的区块记录。
+func emitBlockDetail(block *types.Block, signer types.Signer, receipts []*types.Receipt) error {
+	number := block.NumberU64()
+	record := NewBlockRecord(block)
+	record.Transactions = make([]*TxRecord, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		index := i
+		txRecord := NewTxRecord(tx, signer)
+		txRecord.BlockNumber = &number
+		txRecord.Index = &index
+		if receipts != nil {
+			txRecord.Receipt = NewReceiptRecord(receipts[i])
+		}
+		record.Transactions[i] = txRecord
+	}
+
+	if currentOutputFormat() != OutputCSV {
+		return Emit(record)
+	}
+	for _, txRecord := range record.Transactions {
+		if err := Emit(txRecord); err != nil {
+			return err
+		}
+	}
+	return nil
+}
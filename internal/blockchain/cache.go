@@ -0,0 +1,540 @@
+package blockchain
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 缓存参数
+const (
+	DefaultCacheEntries    = 10000
+	finalizedRefresh       = 30 * time.Second // finalized 高度的刷新间隔
+	finalizedFallbackDepth = 64               // 节点不支持 finalized 标签时，视为已最终确定的确认数 (两个 epoch)
+	cacheKindHeader        = "header"
+	cacheKindBlock         = "block"
+	cacheKindReceipt       = "receipt"
+	cacheKindTransaction   = "tx"
+	cacheKindNumber        = "number"
+	cacheKindUnconfirmedTx = "tx-unconfirmed" // 已打包但回执尚未缓存的交易，只暂存在内存中
+	cacheFilePerm          = 0o644
+	cacheDirPerm           = 0o755
+	cacheFinalizedUnknown  = ^uint64(0)
+)
+
+// CacheOptions 缓存配置
+type CacheOptions struct {
+	MaxEntries int    // 内存 LRU 的最大条目数，0 使用 DefaultCacheEntries
+	Dir        string // 磁盘缓存目录，为空时只使用内存缓存；按链 ID 分子目录，多个网络可共用
+}
+
+// CacheStats 某类数据的缓存命中统计
+type CacheStats struct {
+	Kind     string `json:"kind"`
+	Hits     int    `json:"hits"`     // 内存命中
+	DiskHits int    `json:"diskHits"` // 磁盘命中
+	Misses   int    `json:"misses"`   // 未命中，从节点获取后写入缓存
+	Bypassed int    `json:"bypassed"` // 数据尚未最终确定 (或为 latest 等标签)，直接访问节点且不缓存
+}
+
+// CachingClient 为不可变的链上数据加上缓存的 Client。
+// 按哈希读取的区块头和区块由哈希保证内容不变，总是缓存；按区块号读取的区块头和区块、
+// 交易回执和交易只在所在区块不高于 finalized 高度时缓存，其余请求直接访问节点。
+// 内存中使用 LRU，设置 CacheOptions.Dir 后同时写入磁盘，重新运行时可直接复用。
+type CachingClient struct {
+	Client
+	dir string // 已包含链 ID 子目录
+
+	mu    sync.Mutex
+	lru   *lruCache
+	stats map[string]*CacheStats
+
+	finalMu      sync.Mutex
+	finalized    uint64
+	finalChecked time.Time
+}
+
+// NewCachingClient 创建带缓存的客户端。设置磁盘目录时会读取链 ID，以免不同网络的数据混用。
+func NewCachingClient(ctx context.Context, client Client, opts CacheOptions) (*CachingClient, error) {
+	c := &CachingClient{
+		Client:    client,
+		lru:       newLRUCache(opts.MaxEntries),
+		stats:     make(map[string]*CacheStats),
+		finalized: cacheFinalizedUnknown,
+	}
+	if opts.Dir != "" {
+		chainID, err := client.ChainID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取链 ID 失败: %v", err)
+		}
+		c.dir = filepath.Join(opts.Dir, chainID.String())
+		for _, kind := range []string{cacheKindHeader, cacheKindBlock, cacheKindReceipt, cacheKindTransaction, cacheKindNumber} {
+			if err := os.MkdirAll(filepath.Join(c.dir, kind), cacheDirPerm); err != nil {
+				return nil, fmt.Errorf("创建缓存目录失败: %v", err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Stats 返回各类数据的缓存统计
+func (c *CachingClient) Stats() []CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var stats []CacheStats
+	for _, kind := range []string{cacheKindHeader, cacheKindBlock, cacheKindReceipt, cacheKindTransaction} {
+		if s, ok := c.stats[kind]; ok {
+			stats = append(stats, *s)
+		}
+	}
+	return stats
+}
+
+// LogCacheStats 打印缓存命中统计
+func LogCacheStats(c *CachingClient) {
+	if c == nil {
+		return
+	}
+	for _, s := range c.Stats() {
+		total := s.Hits + s.DiskHits + s.Misses + s.Bypassed
+		if total == 0 {
+			continue
+		}
+		Logger().Info("缓存统计", "kind", s.Kind, "hits", s.Hits+s.DiskHits, "memory_hits", s.Hits, "disk_hits", s.DiskHits,
+			"misses", s.Misses, "bypassed", s.Bypassed, "hit_rate", fmt.Sprintf("%.1f%%", float64(s.Hits+s.DiskHits)*100/float64(total)))
+	}
+}
+
+func (c *CachingClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if h, ok := c.cachedHeader(hash); ok {
+		return h, nil
+	}
+	h, err := c.Client.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.count(cacheKindHeader, func(s *CacheStats) { s.Misses++ })
+	c.storeHeader(h)
+	return h, nil
+}
+
+func (c *CachingClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if !c.isFinal(ctx, number) {
+		c.count(cacheKindHeader, func(s *CacheStats) { s.Bypassed++ })
+		return c.Client.HeaderByNumber(ctx, number)
+	}
+	if hash, ok := c.numberHash(number.Uint64()); ok {
+		if h, ok := c.cachedHeader(hash); ok {
+			return h, nil
+		}
+	}
+	h, err := c.Client.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	c.count(cacheKindHeader, func(s *CacheStats) { s.Misses++ })
+	c.storeHeader(h)
+	c.storeNumber(h.Number.Uint64(), h.Hash())
+	return h, nil
+}
+
+func (c *CachingClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if b, ok := c.cachedBlock(hash); ok {
+		return b, nil
+	}
+	b, err := c.Client.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.count(cacheKindBlock, func(s *CacheStats) { s.Misses++ })
+	c.storeBlock(b)
+	return b, nil
+}
+
+func (c *CachingClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if !c.isFinal(ctx, number) {
+		c.count(cacheKindBlock, func(s *CacheStats) { s.Bypassed++ })
+		return c.Client.BlockByNumber(ctx, number)
+	}
+	if hash, ok := c.numberHash(number.Uint64()); ok {
+		if b, ok := c.cachedBlock(hash); ok {
+			return b, nil
+		}
+	}
+	b, err := c.Client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	c.count(cacheKindBlock, func(s *CacheStats) { s.Misses++ })
+	c.storeBlock(b)
+	c.storeNumber(b.NumberU64(), b.Hash())
+	return b, nil
+}
+
+func (c *CachingClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if r, ok := c.cachedReceipt(txHash); ok {
+		return r, nil
+	}
+	r, err := c.Client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if !c.isFinal(ctx, r.BlockNumber) {
+		c.count(cacheKindReceipt, func(s *CacheStats) { s.Bypassed++ })
+		return r, nil
+	}
+	c.count(cacheKindReceipt, func(s *CacheStats) { s.Misses++ })
+	c.storeReceipt(r)
+	c.promoteTransaction(txHash)
+	return r, nil
+}
+
+// TransactionByHash 交易本身由哈希确定，但所在区块只能从回执得知，
+// 因此只在缓存中已有该交易的最终回执时才直接缓存交易。调用方通常先获取交易再获取回执，
+// 此时已打包的交易先暂存在内存中，等 TransactionReceipt 缓存了最终回执后再写入缓存。
+func (c *CachingClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	if tx, ok := c.cachedTransaction(hash); ok {
+		return tx, false, nil
+	}
+	tx, isPending, err := c.Client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, false, err
+	}
+	if isPending || !c.hasReceipt(hash) {
+		c.count(cacheKindTransaction, func(s *CacheStats) { s.Bypassed++ })
+		if !isPending {
+			c.mu.Lock()
+			c.lru.Add(cacheKindUnconfirmedTx+":"+hash.Hex(), tx)
+			c.mu.Unlock()
+		}
+		return tx, isPending, nil
+	}
+	c.count(cacheKindTransaction, func(s *CacheStats) { s.Misses++ })
+	c.storeTransaction(tx)
+	return tx, false, nil
+}
+
+// BlockReceipts 获取区块全部回执；区块已最终确定时逐笔缓存回执
+func (c *CachingClient) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var block *types.Block
+	var err error
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = c.BlockByHash(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		block, err = c.BlockByNumber(ctx, big.NewInt(number.Int64()))
+	} else {
+		return nil, fmt.Errorf("无效的区块参数")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	final := c.isFinal(ctx, block.Number())
+	if final {
+		receipts := make([]*types.Receipt, 0, len(block.Transactions()))
+		for _, tx := range block.Transactions() {
+			r, ok := c.cachedReceipt(tx.Hash())
+			if !ok {
+				break
+			}
+			receipts = append(receipts, r)
+		}
+		if len(receipts) == len(block.Transactions()) {
+			return receipts, nil
+		}
+	}
+
+	receipts, err := blockReceipts(ctx, c.Client, block)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range receipts {
+		if final {
+			c.count(cacheKindReceipt, func(s *CacheStats) { s.Misses++ })
+			c.storeReceipt(r)
+			c.promoteTransaction(r.TxHash)
+		} else {
+			c.count(cacheKindReceipt, func(s *CacheStats) { s.Bypassed++ })
+		}
+	}
+	return receipts, nil
+}
+
+// NetworkSettings、BalanceAtHash 与 EstimateGasAtBlock 不缓存，只保留底层客户端的扩展能力
+func (c *CachingClient) NetworkSettings() NetworkSettings {
+	return NetworkOf(c.Client)
+}
+
+func (c *CachingClient) BalanceAtHash(ctx context.Context, account common.Address, hash common.Hash) (*big.Int, error) {
+	return balanceAtHash(ctx, c.Client, account, hash)
+}
+
+func (c *CachingClient) EstimateGasAtBlock(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (uint64, error) {
+	return estimateGasAtBlock(ctx, c.Client, msg, blockNumber)
+}
+
+// isFinal 判断区块号是否不高于 finalized 高度；nil 与 latest 等标签 (负数) 均不是
+func (c *CachingClient) isFinal(ctx context.Context, number *big.Int) bool {
+	if number == nil || number.Sign() < 0 || !number.IsUint64() {
+		return false
+	}
+	finalized := c.finalizedNumber(ctx)
+	return finalized != cacheFinalizedUnknown && number.Uint64() <= finalized
+}
+
+// finalizedNumber 返回 finalized 高度，每 finalizedRefresh 刷新一次。
+// 节点不支持 finalized 标签时使用 latest - finalizedFallbackDepth；都获取失败时返回 cacheFinalizedUnknown。
+func (c *CachingClient) finalizedNumber(ctx context.Context) uint64 {
+	c.finalMu.Lock()
+	defer c.finalMu.Unlock()
+	if time.Since(c.finalChecked) < finalizedRefresh {
+		return c.finalized
+	}
+
+	c.finalChecked = time.Now()
+	header, err := c.Client.HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+	if err == nil {
+		c.finalized = header.Number.Uint64()
+		return c.finalized
+	}
+	c.finalized = cacheFinalizedUnknown
+	latest, latestErr := c.Client.BlockNumber(ctx)
+	if latestErr != nil {
+		Logger().Warn("获取 finalized 高度失败，暂不使用按区块号的缓存", logErr(err))
+	} else if latest >= finalizedFallbackDepth {
+		c.finalized = latest - finalizedFallbackDepth
+	}
+	return c.finalized
+}
+
+func (c *CachingClient) count(kind string, f func(*CacheStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stats[kind]
+	if !ok {
+		s = &CacheStats{Kind: kind}
+		c.stats[kind] = s
+	}
+	f(s)
+}
+
+// lookup 依次查找内存和磁盘，磁盘命中时回填内存；decode 解析磁盘上的数据
+func (c *CachingClient) lookup(kind, key string, decode func([]byte) (any, error)) (any, bool) {
+	memKey := kind + ":" + key
+	c.mu.Lock()
+	v, ok := c.lru.Get(memKey)
+	c.mu.Unlock()
+	if ok {
+		c.count(kind, func(s *CacheStats) { s.Hits++ })
+		return v, true
+	}
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, kind, key))
+	if err != nil {
+		return nil, false
+	}
+	v, err = decode(data)
+	if err != nil {
+		Logger().Warn("磁盘缓存已损坏，忽略", "kind", kind, "key", key, logErr(err))
+		return nil, false
+	}
+	c.mu.Lock()
+	c.lru.Add(memKey, v)
+	c.mu.Unlock()
+	c.count(kind, func(s *CacheStats) { s.DiskHits++ })
+	return v, true
+}
+
+// store 写入内存，并在启用磁盘缓存时原子写入文件 (先写临时文件再重命名)
+func (c *CachingClient) store(kind, key string, v any, encode func() ([]byte, error)) {
+	c.mu.Lock()
+	c.lru.Add(kind+":"+key, v)
+	c.mu.Unlock()
+	if c.dir == "" {
+		return
+	}
+	data, err := encode()
+	if err != nil {
+		Logger().Warn("编码缓存数据失败", "kind", kind, "key", key, logErr(err))
+		return
+	}
+	path := filepath.Join(c.dir, kind, key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, cacheFilePerm); err != nil {
+		Logger().Warn("写入磁盘缓存失败", logErr(err))
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		Logger().Warn("写入磁盘缓存失败", logErr(err))
+	}
+}
+
+func (c *CachingClient) cachedHeader(hash common.Hash) (*types.Header, bool) {
+	v, ok := c.lookup(cacheKindHeader, hash.Hex(), func(data []byte) (any, error) {
+		h := new(types.Header)
+		return h, rlp.DecodeBytes(data, h)
+	})
+	if !ok {
+		return nil, false
+	}
+	return v.(*types.Header), true
+}
+
+func (c *CachingClient) storeHeader(h *types.Header) {
+	c.store(cacheKindHeader, h.Hash().Hex(), h, func() ([]byte, error) { return rlp.EncodeToBytes(h) })
+}
+
+func (c *CachingClient) cachedBlock(hash common.Hash) (*types.Block, bool) {
+	v, ok := c.lookup(cacheKindBlock, hash.Hex(), func(data []byte) (any, error) {
+		b := new(types.Block)
+		return b, rlp.DecodeBytes(data, b)
+	})
+	if !ok {
+		return nil, false
+	}
+	return v.(*types.Block), true
+}
+
+// storeBlock 缓存区块，同时缓存其区块头
+func (c *CachingClient) storeBlock(b *types.Block) {
+	c.store(cacheKindBlock, b.Hash().Hex(), b, func() ([]byte, error) { return rlp.EncodeToBytes(b) })
+	c.storeHeader(b.Header())
+}
+
+// 回执的派生字段 (区块哈希、交易哈希、合约地址等) 不在 RLP 编码中，因此使用 JSON
+func (c *CachingClient) cachedReceipt(txHash common.Hash) (*types.Receipt, bool) {
+	v, ok := c.lookup(cacheKindReceipt, txHash.Hex(), func(data []byte) (any, error) {
+		r := new(types.Receipt)
+		return r, json.Unmarshal(data, r)
+	})
+	if !ok {
+		return nil, false
+	}
+	return v.(*types.Receipt), true
+}
+
+func (c *CachingClient) storeReceipt(r *types.Receipt) {
+	c.store(cacheKindReceipt, r.TxHash.Hex(), r, func() ([]byte, error) { return json.Marshal(r) })
+}
+
+// hasReceipt 是否已缓存该交易的回执，不计入命中统计
+func (c *CachingClient) hasReceipt(txHash common.Hash) bool {
+	c.mu.Lock()
+	_, ok := c.lru.Get(cacheKindReceipt + ":" + txHash.Hex())
+	c.mu.Unlock()
+	if ok || c.dir == "" {
+		return ok
+	}
+	_, err := os.Stat(filepath.Join(c.dir, cacheKindReceipt, txHash.Hex()))
+	return err == nil
+}
+
+func (c *CachingClient) cachedTransaction(hash common.Hash) (*types.Transaction, bool) {
+	v, ok := c.lookup(cacheKindTransaction, hash.Hex(), func(data []byte) (any, error) {
+		tx := new(types.Transaction)
+		return tx, tx.UnmarshalBinary(data)
+	})
+	if !ok {
+		return nil, false
+	}
+	return v.(*types.Transaction), true
+}
+
+func (c *CachingClient) storeTransaction(tx *types.Transaction) {
+	c.store(cacheKindTransaction, tx.Hash().Hex(), tx, tx.MarshalBinary)
+}
+
+// promoteTransaction 交易的最终回执已缓存后，将 TransactionByHash 暂存的交易写入缓存
+func (c *CachingClient) promoteTransaction(txHash common.Hash) {
+	key := cacheKindUnconfirmedTx + ":" + txHash.Hex()
+	c.mu.Lock()
+	v, ok := c.lru.Get(key)
+	c.mu.Unlock()
+	if ok {
+		c.storeTransaction(v.(*types.Transaction))
+	}
+}
+
+// numberHash 查找已最终确定的区块号对应的区块哈希
+func (c *CachingClient) numberHash(number uint64) (common.Hash, bool) {
+	key := strconv.FormatUint(number, 10)
+	c.mu.Lock()
+	v, ok := c.lru.Get(cacheKindNumber + ":" + key)
+	c.mu.Unlock()
+	if ok {
+		return v.(common.Hash), true
+	}
+	if c.dir == "" {
+		return common.Hash{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheKindNumber, key))
+	if err != nil || len(data) != common.HashLength {
+		return common.Hash{}, false
+	}
+	hash := common.BytesToHash(data)
+	c.mu.Lock()
+	c.lru.Add(cacheKindNumber+":"+key, hash)
+	c.mu.Unlock()
+	return hash, true
+}
+
+func (c *CachingClient) storeNumber(number uint64, hash common.Hash) {
+	c.store(cacheKindNumber, strconv.FormatUint(number, 10), hash, func() ([]byte, error) { return hash.Bytes(), nil })
+}
+
+// lruCache 固定容量的最近最少使用缓存，非并发安全
+type lruCache struct {
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value any
+}
+
+func newLRUCache(capacity int) *lruCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheEntries
+	}
+	return &lruCache{capacity: capacity, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (l *lruCache) Get(key string) (any, bool) {
+	e, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	l.ll.MoveToFront(e)
+	return e.Value.(*lruEntry).value, true
+}
+
+func (l *lruCache) Add(key string, value any) {
+	if e, ok := l.items[key]; ok {
+		e.Value.(*lruEntry).value = value
+		l.ll.MoveToFront(e)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{key: key, value: value})
+	if l.ll.Len() > l.capacity {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry).key)
+	}
+}
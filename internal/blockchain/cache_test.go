@@ -0,0 +1,231 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// cacheTestClient 模拟节点：区块 1..head 各含一笔交易，finalized 高度固定，并记录每类请求的次数
+type cacheTestClient struct {
+	Client
+	head      uint64
+	finalized uint64
+	blocks    map[uint64]*types.Block
+	receipts  map[common.Hash]*types.Receipt
+	txs       map[common.Hash]*types.Transaction
+	pending   *types.Transaction
+	calls     map[string]int
+}
+
+func newCacheTestClient(head, finalized uint64) *cacheTestClient {
+	c := &cacheTestClient{
+		head:      head,
+		finalized: finalized,
+		blocks:    make(map[uint64]*types.Block),
+		receipts:  make(map[common.Hash]*types.Receipt),
+		txs:       make(map[common.Hash]*types.Transaction),
+		pending:   types.NewTx(&types.LegacyTx{Nonce: head + 1, Gas: 21000, GasPrice: big.NewInt(1), Value: big.NewInt(1)}),
+		calls:     make(map[string]int),
+	}
+	for n := uint64(1); n <= head; n++ {
+		tx := types.NewTx(&types.LegacyTx{Nonce: n, Gas: 21000, GasPrice: big.NewInt(1), Value: big.NewInt(int64(n))})
+		header := &types.Header{Number: new(big.Int).SetUint64(n), Time: 1700000000 + n*12, GasLimit: 30_000_000, GasUsed: 21000}
+		block := types.NewBlockWithHeader(header).WithBody(types.Body{Transactions: []*types.Transaction{tx}})
+		c.blocks[n] = block
+		c.txs[tx.Hash()] = tx
+		c.receipts[tx.Hash()] = &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000,
+			GasUsed:           21000,
+			Logs:              []*types.Log{},
+			TxHash:            tx.Hash(),
+			BlockHash:         block.Hash(),
+			BlockNumber:       new(big.Int).SetUint64(n),
+		}
+	}
+	return c
+}
+
+// blockAt 按区块号或标签返回区块；finalized 标签不计入请求次数
+func (c *cacheTestClient) blockAt(number *big.Int, kind string) (*types.Block, error) {
+	n := c.head
+	switch {
+	case number != nil && number.Int64() == int64(rpc.FinalizedBlockNumber):
+		return c.blocks[c.finalized], nil
+	case number != nil && number.Sign() >= 0:
+		n = number.Uint64()
+	}
+	c.calls[kind]++
+	b, ok := c.blocks[n]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return b, nil
+}
+
+func (c *cacheTestClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1337), nil
+}
+
+func (c *cacheTestClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b, err := c.blockAt(number, cacheKindHeader)
+	if err != nil {
+		return nil, err
+	}
+	return b.Header(), nil
+}
+
+func (c *cacheTestClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return c.blockAt(number, cacheKindBlock)
+}
+
+func (c *cacheTestClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	c.calls[cacheKindReceipt]++
+	r, ok := c.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return r, nil
+}
+
+func (c *cacheTestClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	c.calls[cacheKindTransaction]++
+	if hash == c.pending.Hash() {
+		return c.pending, true, nil
+	}
+	tx, ok := c.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return tx, false, nil
+}
+
+func TestCachingClientBypassesUnfinalizedData(t *testing.T) {
+	ctx := context.Background()
+	node := newCacheTestClient(20, 10)
+	client, err := NewCachingClient(ctx, node, CacheOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unfinal := node.blocks[15].Transactions()[0].Hash()
+
+	tests := []struct {
+		name  string
+		kind  string
+		fetch func() error
+		calls int // 请求两次后实际访问节点的次数
+	}{
+		{"已最终确定的区块", cacheKindBlock, func() error { _, err := client.BlockByNumber(ctx, big.NewInt(5)); return err }, 1},
+		{"未最终确定的区块", cacheKindBlock, func() error { _, err := client.BlockByNumber(ctx, big.NewInt(15)); return err }, 2},
+		{"latest 区块头", cacheKindHeader, func() error { _, err := client.HeaderByNumber(ctx, nil); return err }, 2},
+		{"未最终确定的回执", cacheKindReceipt, func() error { _, err := client.TransactionReceipt(ctx, unfinal); return err }, 2},
+		{"pending 交易", cacheKindTransaction, func() error { _, _, err := client.TransactionByHash(ctx, node.pending.Hash()); return err }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node.calls = make(map[string]int)
+			for i := 0; i < 2; i++ {
+				if err := tt.fetch(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := node.calls[tt.kind]; got != tt.calls {
+				t.Errorf("节点请求次数 = %d, 期望 %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestCachingClientCachesTransactionAfterReceipt(t *testing.T) {
+	ctx := context.Background()
+	node := newCacheTestClient(20, 10)
+	client, err := NewCachingClient(ctx, node, CacheOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 与 GetTxInfo 相同的顺序：先获取交易，再获取回执
+	final := node.blocks[5].Transactions()[0].Hash()
+	unfinal := node.blocks[15].Transactions()[0].Hash()
+	for _, hash := range []common.Hash{final, unfinal} {
+		for i := 0; i < 2; i++ {
+			if _, _, err := client.TransactionByHash(ctx, hash); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.TransactionReceipt(ctx, hash); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// 最终确定的交易只在第一次访问节点；未最终确定的交易每次都访问节点
+	if got := node.calls[cacheKindTransaction]; got != 3 {
+		t.Errorf("交易请求次数 = %d, 期望 3", got)
+	}
+	if got := node.calls[cacheKindReceipt]; got != 3 {
+		t.Errorf("回执请求次数 = %d, 期望 3", got)
+	}
+}
+
+func TestCachingClientDiskRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	node := newCacheTestClient(20, 10)
+	txHash := node.blocks[5].Transactions()[0].Hash()
+
+	first, err := NewCachingClient(ctx, node, CacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBlock, err := first.BlockByNumber(ctx, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReceipt, err := first.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 新的客户端 (内存为空) 应从磁盘读取，不再访问节点
+	node.calls = make(map[string]int)
+	second, err := NewCachingClient(ctx, node, CacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := second.BlockByNumber(ctx, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := second.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := second.HeaderByNumber(ctx, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(node.calls) != 0 {
+		t.Errorf("磁盘缓存命中时不应访问节点，实际请求: %v", node.calls)
+	}
+	if block.Hash() != wantBlock.Hash() || len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != txHash {
+		t.Errorf("区块不一致: %s, 期望 %s", block.Hash(), wantBlock.Hash())
+	}
+	if header.Hash() != wantBlock.Hash() {
+		t.Errorf("区块头哈希 = %s, 期望 %s", header.Hash(), wantBlock.Hash())
+	}
+	if receipt.TxHash != wantReceipt.TxHash || receipt.BlockHash != wantReceipt.BlockHash ||
+		receipt.BlockNumber.Cmp(wantReceipt.BlockNumber) != 0 || receipt.GasUsed != wantReceipt.GasUsed || receipt.Status != wantReceipt.Status {
+		t.Errorf("回执不一致: %+v, 期望 %+v", receipt, wantReceipt)
+	}
+	for _, s := range second.Stats() {
+		if s.DiskHits == 0 || s.Misses != 0 {
+			t.Errorf("%s 统计 = %+v, 期望全部为磁盘命中", s.Kind, s)
+		}
+	}
+}
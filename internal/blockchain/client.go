@@ -2,40 +2,150 @@ package blockchain
 
 import (
 	"context"
-	"log"
-	"sync"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// Client 是本包读写链上数据所需的节点接口。
+// *ethclient.Client、本包的 *NodeClient 以及 go-ethereum 模拟后端 (ethclient/simulated) 的 Client 均满足该接口，
+// 因此可以在同一进程中连接多个网络，也可以在测试中注入模拟后端。
+// 按区块哈希读余额、批量获取回执等扩展方法不在接口中，由 balanceAtHash 等辅助函数按需探测并降级。
+type Client interface {
+	bind.ContractBackend
+	ethereum.BlockNumberReader
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ChainIDReader
+	ethereum.FeeHistoryReader
+	ethereum.PendingStateReader
+	ethereum.TransactionReader
+}
+
 var (
-	clientInstance *ethclient.Client
-	once           sync.Once
+	_ Client = (*ethclient.Client)(nil)
+	_ Client = (*NodeClient)(nil)
 )
 
-// GetClient 返回以太坊客户端的单例实例。
-// 如果客户端已初始化，则返回现有实例。
-// 否则，它将使用提供的 URL 连接到以太坊网络。
-func GetClient(url string) *ethclient.Client {
-	once.Do(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+// NodeClient 连接真实节点的客户端。
+// 提供多个 HTTP(S) 地址时按优先级进行故障转移 (见 FailoverTransport)，后台定期检查节点健康状态。
+type NodeClient struct {
+	*ethclient.Client
+	Network    NetworkSettings // 所选网络的设置 (期望链 ID、确认数、区块浏览器)，通过 NetworkOf 读取
+	transport  *FailoverTransport
+	stopHealth context.CancelFunc
+}
+
+// NetworkSettings 返回客户端附带的网络设置 (供 NetworkOf 使用)
+func (c *NodeClient) NetworkSettings() NetworkSettings {
+	return c.Network
+}
+
+// Dial 使用提供的 URL 连接以太坊网络，每次调用返回一个独立的客户端，使用完毕后需调用 Close。
+// limiter 不为 nil 时所有 HTTP 请求 (包括健康检查) 经过限流器，多个客户端可共享同一个限流器。
+// 非 HTTP 地址 (如 ws:// 或 IPC) 不支持故障转移和限流，只连接第一个。
+func Dial(ctx context.Context, limiter *RateLimiter, urls ...string) (*NodeClient, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("连接以太坊客户端失败: 未配置 RPC 地址")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-		var err error
-		clientInstance, err = ethclient.DialContext(ctx, url)
+	if !strings.HasPrefix(urls[0], "http://") && !strings.HasPrefix(urls[0], "https://") {
+		client, err := ethclient.DialContext(ctx, urls[0])
 		if err != nil {
-			log.Fatalf("连接以太坊客户端失败: %v", err)
+			return nil, fmt.Errorf("连接以太坊客户端失败: %v", err)
 		}
-		log.Println("以太坊客户端连接成功 (单例已初始化)")
-	})
-	return clientInstance
+		Logger().Info("以太坊客户端连接成功", logEndpoint(urls[0]))
+		return &NodeClient{Client: client}, nil
+	}
+
+	transport, err := NewFailoverTransport(urls)
+	if err != nil {
+		return nil, fmt.Errorf("连接以太坊客户端失败: %v", err)
+	}
+	if limiter != nil {
+		transport.base = &rateLimitTransport{base: transport.base, limiter: limiter}
+	}
+	rpcClient, err := rpc.DialOptions(ctx, urls[0], rpc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		return nil, fmt.Errorf("连接以太坊客户端失败: %v", err)
+	}
+	transport.CheckHealth(ctx)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	go transport.Run(healthCtx)
+
+	Logger().Info("以太坊客户端连接成功", "endpoints", len(urls))
+	return &NodeClient{Client: ethclient.NewClient(rpcClient), transport: transport, stopHealth: stopHealth}, nil
+}
+
+// EndpointStatuses 返回各 RPC 节点的健康状态；未启用故障转移时返回 nil
+func (c *NodeClient) EndpointStatuses() []EndpointStatus {
+	if c.transport == nil {
+		return nil
+	}
+	return c.transport.Status()
+}
+
+// Close 停止健康检查并关闭连接
+func (c *NodeClient) Close() {
+	if c.stopHealth != nil {
+		c.stopHealth()
+	}
+	c.Client.Close()
+	Logger().Info("以太坊客户端连接已关闭")
+}
+
+// balanceAtHash 读取账户在指定区块哈希处的余额。
+// 客户端不支持 eth_getBalance 的区块哈希参数时 (如模拟后端)，先将哈希解析为区块号再读取。
+func balanceAtHash(ctx context.Context, client Client, account common.Address, hash common.Hash) (*big.Int, error) {
+	if c, ok := client.(interface {
+		BalanceAtHash(context.Context, common.Address, common.Hash) (*big.Int, error)
+	}); ok {
+		return c.BalanceAtHash(ctx, account, hash)
+	}
+	header, err := client.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return client.BalanceAt(ctx, account, header.Number)
+}
+
+// blockReceipts 获取区块内全部回执。
+// 客户端不支持 eth_getBlockReceipts 时逐笔查询交易回执。
+func blockReceipts(ctx context.Context, client Client, block *types.Block) ([]*types.Receipt, error) {
+	if c, ok := client.(interface {
+		BlockReceipts(context.Context, rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+	}); ok {
+		return c.BlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+	}
+	receipts := make([]*types.Receipt, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("获取交易 %s 回执失败: %v", tx.Hash().Hex(), err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
 }
 
-// CloseClient 关闭单例客户端连接（如果存在）。
-func CloseClient() {
-	if clientInstance != nil {
-		clientInstance.Close()
-		log.Println("以太坊客户端连接已关闭")
+// estimateGasAtBlock 在指定区块估算 Gas。
+// 客户端不支持指定区块时退回 EstimateGas (基于 pending 状态)。
+func estimateGasAtBlock(ctx context.Context, client Client, msg ethereum.CallMsg, blockNumber *big.Int) (uint64, error) {
+	if c, ok := client.(interface {
+		EstimateGasAtBlock(context.Context, ethereum.CallMsg, *big.Int) (uint64, error)
+	}); ok {
+		return c.EstimateGasAtBlock(ctx, msg, blockNumber)
 	}
+	return client.EstimateGas(ctx, msg)
 }
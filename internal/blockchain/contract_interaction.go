@@ -1,90 +1,265 @@
 package blockchain
 
 import (
+	"bytes"
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"math/big"
-	"strings"
+	"time"
 
 	"sun-DappBackend-homework/internal/contract"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 // DeployContract 将 Counter 合约部署到网络
-func DeployContract(client *ethclient.Client, privateKeyHex string) (string, error) {
+// 它会等待部署交易上链，并校验链上的运行时代码与 contract.ContractBin 的预期一致，
+// 返回的部署记录可通过 RecordDeployment 写入本地部署记录文件。
+func DeployContract(client Client, privateKeyHex string, name string) (*Deployment, error) {
 	auth, err := getTransactOpts(client, privateKeyHex)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	address, tx, _, err := contract.DeployContract(auth, client)
+	address, tx, _, err := contract.DeployContract(auth, retrySendClient{client})
 	if err != nil {
-		return "", fmt.Errorf("部署合约失败: %v", err)
+		return nil, fmt.Errorf("部署合约失败: %w", decodeCounterError(err))
 	}
 
-	fmt.Printf("合约部署已启动。交易哈希: %s\n", tx.Hash().Hex())
-	fmt.Printf("合约地址: %s\n", address.Hex())
-	return address.Hex(), nil
+	fmt.Fprintf(TextOut(), "合约部署已启动。交易哈希: %s\n", tx.Hash().Hex())
+	fmt.Fprintf(TextOut(), "预测合约地址: %s\n", address.Hex())
+	Logger().Info("正在等待部署交易上链", logTx(tx.Hash()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	receipt, err := waitForReceipt(ctx, client, tx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("等待部署回执失败 (交易 %s): %v", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("部署交易执行失败 (交易 %s, 区块 %d)", tx.Hash().Hex(), receipt.BlockNumber.Uint64())
+	}
+	if receipt.ContractAddress != address {
+		return nil, fmt.Errorf("回执中的合约地址 %s 与预测地址 %s 不一致", receipt.ContractAddress.Hex(), address.Hex())
+	}
+
+	code, err := verifyDeployedCode(ctx, client, auth.From, address, common.FromHex(contract.ContractBin), receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+
+	return &Deployment{
+		Name:         name,
+		Contract:     "Counter",
+		Network:      ChainName(chainID),
+		ChainID:      chainID.Uint64(),
+		Address:      address.Hex(),
+		TxHash:       tx.Hash().Hex(),
+		Block:        receipt.BlockNumber.Uint64(),
+		Deployer:     auth.From.Hex(),
+		BytecodeHash: crypto.Keccak256Hash(code).Hex(),
+		DeployedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// RecordDeployment 将部署记录追加到本地部署记录文件
+func RecordDeployment(registryPath string, d *Deployment) error {
+	reg, err := LoadRegistry(registryPath)
+	if err != nil {
+		return err
+	}
+	reg.Add(*d)
+	return reg.Save(registryPath)
+}
+
+// verifyDeployedCode 校验链上的运行时代码。
+// 预期代码通过 eth_call 执行创建代码得到 (即构造函数的返回值)，与链上代码逐字节比较。
+func verifyDeployedCode(ctx context.Context, client Client, deployer common.Address, address common.Address, creationCode []byte, block *big.Int) ([]byte, error) {
+	code, err := client.CodeAt(ctx, address, block)
+	if err != nil {
+		return nil, fmt.Errorf("读取合约代码失败: %v", err)
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("地址 %s 上没有合约代码", address.Hex())
+	}
+
+	expected, err := client.CallContract(ctx, ethereum.CallMsg{From: deployer, Data: creationCode}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("计算预期运行时代码失败: %w", DecodeContractError(err))
+	}
+	if !bytes.Equal(code, expected) {
+		return nil, fmt.Errorf("地址 %s 上的运行时代码与预期不一致 (链上 %d 字节, 预期 %d 字节)", address.Hex(), len(code), len(expected))
+	}
+	return code, nil
 }
 
 // IncrementCounter 调用 Counter 合约的 increment 函数
-func IncrementCounter(client *ethclient.Client, privateKeyHex string, contractAddressHex string) error {
+func IncrementCounter(client Client, privateKeyHex string, contractAddressHex string) error {
 	auth, err := getTransactOpts(client, privateKeyHex)
 	if err != nil {
 		return err
 	}
 
 	contractAddress := common.HexToAddress(contractAddressHex)
-	counter, err := contract.NewContract(contractAddress, client)
+	counter, err := contract.NewContract(contractAddress, retrySendClient{client})
 	if err != nil {
 		return fmt.Errorf("加载合约失败: %v", err)
 	}
 
 	tx, err := counter.Increment(auth)
 	if err != nil {
-		return fmt.Errorf("增加计数器失败: %v", err)
+		return fmt.Errorf("增加计数器失败: %w", decodeCounterError(err))
 	}
 
-	fmt.Printf("增加交易已发送。交易哈希: %s\n", tx.Hash().Hex())
+	PrintTxSent(client, "增加交易已发送", tx)
 	return nil
 }
 
-// GetCounterValue 读取 Counter 合约的当前计数值
-func GetCounterValue(client *ethclient.Client, contractAddressHex string) (string, error) {
+// GetCounterValue 读取 Counter 合约在指定区块的计数值 (零值 BlockRef 表示最新区块)
+func GetCounterValue(client Client, contractAddressHex string, block BlockRef) (string, error) {
 	contractAddress := common.HexToAddress(contractAddressHex)
 	counter, err := contract.NewContract(contractAddress, client)
 	if err != nil {
 		return "", fmt.Errorf("加载合约失败: %v", err)
 	}
 
-	count, err := counter.GetCount(nil)
+	count, err := counter.GetCount(block.CallOpts(context.Background()))
 	if err != nil {
-		return "", fmt.Errorf("获取计数值失败: %v", err)
+		return "", fmt.Errorf("获取计数值失败: %w", wrapStateError(decodeCounterError(err), block))
 	}
 
 	return count.String(), nil
 }
 
-// 创建交易选项的辅助函数
-func getTransactOpts(client *ethclient.Client, privateKeyHex string) (*bind.TransactOpts, error) {
-	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+// CounterValue 批量读取时单个 Counter 合约的结果
+type CounterValue struct {
+	Address string
+	Count   *big.Int
+	Err     error
+}
+
+// GetCounterValues 通过 Multicall3 在同一区块批量读取多个 Counter 合约的计数值，
+// 返回结果顺序与输入一致，以及实际读取的区块号。
+func GetCounterValues(client Client, contractAddressesHex []string, block BlockRef) ([]CounterValue, *big.Int, error) {
+	parsed, err := contract.ContractMetaData.GetAbi()
 	if err != nil {
-		return nil, fmt.Errorf("无效的私钥: %v", err)
+		return nil, nil, fmt.Errorf("解析合约 ABI 失败: %v", err)
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("无法将公钥转换为 ECDSA")
+	calls := make([]MulticallCall, len(contractAddressesHex))
+	for i, addr := range contractAddressesHex {
+		if !common.IsHexAddress(addr) {
+			return nil, nil, fmt.Errorf("无效的合约地址: %s", addr)
+		}
+		calls[i] = MulticallCall{Target: common.HexToAddress(addr), ABI: parsed, Method: "getCount"}
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+	var blockNumber *big.Int
+	if !block.IsLatest() {
+		blockNumber, err = ResolveBlockNumber(context.Background(), client, block)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	results, blockNumber, err := NewMulticaller(client).Aggregate(context.Background(), calls, blockNumber)
+	if err != nil {
+		return nil, nil, wrapStateError(err, block)
+	}
+
+	values := make([]CounterValue, len(results))
+	for i, r := range results {
+		values[i].Address = calls[i].Target.Hex()
+		if !r.Success {
+			values[i].Err = r.Err
+			continue
+		}
+		values[i].Count = r.Values[0].(*big.Int)
+	}
+	return values, blockNumber, nil
+}
+
+// CounterRecord 计数器读取结果的结构化输出
+type CounterRecord struct {
+	Contract string `json:"contract"`
+	Block    string `json:"block"` // 区块号或标签
+	Count    string `json:"count,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (r *CounterRecord) CSVHeader() []string {
+	return []string{"contract", "block", "count", "error"}
+}
+
+func (r *CounterRecord) CSVRow() []string {
+	return []string{r.Contract, r.Block, r.Count, r.Error}
+}
+
+// PrintCounterValue 打印单个计数器值；非 text 格式时输出 CounterRecord
+func PrintCounterValue(contractAddressHex string, block BlockRef, count string) error {
+	if !IsTextOutput() {
+		return Emit(&CounterRecord{Contract: common.HexToAddress(contractAddressHex).Hex(), Block: block.String(), Count: count})
+	}
+	if block.IsLatest() {
+		fmt.Fprintf(TextOut(), "当前计数器值: %s\n", count)
+	} else {
+		fmt.Fprintf(TextOut(), "区块 %s 时的计数器值: %s\n", block, count)
+	}
+	return nil
+}
+
+// PrintCounterValues 打印批量读取的计数器值；非 text 格式时逐个输出 CounterRecord
+func PrintCounterValues(values []CounterValue, blockNumber *big.Int) error {
+	if !IsTextOutput() {
+		for _, v := range values {
+			r := &CounterRecord{Contract: v.Address, Block: blockNumber.String()}
+			if v.Err != nil {
+				r.Error = v.Err.Error()
+			} else {
+				r.Count = v.Count.String()
+			}
+			if err := Emit(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	fmt.Fprintf(TextOut(), "区块 %s 时的计数器值:\n", blockNumber)
+	for _, v := range values {
+		if v.Err != nil {
+			fmt.Fprintf(TextOut(), "  %s: 读取失败 (%v)\n", v.Address, v.Err)
+		} else {
+			fmt.Fprintf(TextOut(), "  %s: %s\n", v.Address, v.Count)
+		}
+	}
+	return nil
+}
+
+// decodeCounterError 使用 Counter 合约的 ABI 解码 revert 数据
+func decodeCounterError(err error) error {
+	parsed, abiErr := contract.ContractMetaData.GetAbi()
+	if abiErr != nil {
+		return DecodeContractError(err)
+	}
+	return DecodeContractError(err, parsed)
+}
+
+// 创建交易选项的辅助函数
+func getTransactOpts(client Client, privateKeyHex string) (*bind.TransactOpts, error) {
+	privateKey, fromAddress, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
 
 	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
 	if err != nil {
@@ -95,6 +270,9 @@ func getTransactOpts(client *ethclient.Client, privateKeyHex string) (*bind.Tran
 	if err != nil {
 		return nil, fmt.Errorf("获取链 ID 失败: %v", err)
 	}
+	if err := checkChainID(chainID, NetworkOf(client).ChainID); err != nil {
+		return nil, fmt.Errorf("拒绝签名: %w", err)
+	}
 
 	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
 	if err != nil {
@@ -106,22 +284,11 @@ func getTransactOpts(client *ethclient.Client, privateKeyHex string) (*bind.Tran
 	auth.GasLimit = uint64(300000) // 单位: units
 
 	// EIP-1559 动态费用
-	gasTipCap, err := client.SuggestGasTipCap(context.Background())
+	gasTipCap, gasFeeCap, err := suggestDynamicFees(context.Background(), client)
 	if err != nil {
-		return nil, fmt.Errorf("获取 gas tip cap 失败: %v", err)
+		return nil, err
 	}
 
-	header, err := client.HeaderByNumber(context.Background(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("获取最新区块头失败: %v", err)
-	}
-
-	baseFee := header.BaseFee
-	gasFeeCap := new(big.Int).Add(
-		new(big.Int).Mul(baseFee, big.NewInt(2)),
-		gasTipCap,
-	)
-
 	auth.GasFeeCap = gasFeeCap
 	auth.GasTipCap = gasTipCap
 
@@ -0,0 +1,233 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"sun-DappBackend-homework/internal/contract"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// logQueryBlockRange 单次 eth_getLogs 查询的最大区块跨度，避免超出节点限制
+const logQueryBlockRange = 5000
+
+// CounterPoint 计数值时间序列中的一个点
+type CounterPoint struct {
+	Block     uint64    `json:"block"`
+	Timestamp time.Time `json:"timestamp"`
+	TxHash    string    `json:"txHash,omitempty"`
+	LogIndex  uint      `json:"logIndex"`
+	Count     string    `json:"count"`
+	Source    string    `json:"source"` // event: 来自 CountIncremented 事件；state: 来自历史 GetCount 读取
+}
+
+// CounterHistoryOptions 计数历史重建选项
+type CounterHistoryOptions struct {
+	StartBlock uint64 // 必填 (通常为合约部署区块)，避免误从创世区块开始扫描整条链
+	EndBlock   uint64 // 0 表示最新区块
+	SpotChecks int    // 使用历史 GetCount 抽查的点数
+}
+
+// CounterHistory 根据 CountIncremented 事件重建 Counter 合约在区块范围内的计数变化。
+// 起始点取 StartBlock-1 时的历史状态 (若节点支持)，之后每个事件产生一个点；
+// 另外在若干事件所在区块用 GetCount 抽查，结果不一致时返回错误。
+func CounterHistory(ctx context.Context, client Client, contractAddressHex string, opts CounterHistoryOptions) ([]CounterPoint, error) {
+	if !common.IsHexAddress(contractAddressHex) {
+		return nil, fmt.Errorf("无效的合约地址: %s", contractAddressHex)
+	}
+	if opts.StartBlock == 0 {
+		return nil, fmt.Errorf("请通过 -start-block 指定起始区块，通常为合约部署区块 (从创世区块扫描整条链的代价过高)")
+	}
+	counter, err := contract.NewContract(common.HexToAddress(contractAddressHex), client)
+	if err != nil {
+		return nil, fmt.Errorf("加载合约失败: %v", err)
+	}
+
+	if opts.EndBlock == 0 {
+		latest, err := client.BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取最新区块号失败: %v", err)
+		}
+		opts.EndBlock = latest
+	}
+	if opts.StartBlock > opts.EndBlock {
+		return nil, fmt.Errorf("起始区块 %d 大于结束区块 %d", opts.StartBlock, opts.EndBlock)
+	}
+
+	timestamps := map[uint64]time.Time{}
+	blockTime := func(number uint64) (time.Time, error) {
+		if ts, ok := timestamps[number]; ok {
+			return ts, nil
+		}
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("获取区块 %d 失败: %v", number, err)
+		}
+		ts := time.Unix(int64(header.Time), 0).UTC()
+		timestamps[number] = ts
+		return ts, nil
+	}
+
+	var points []CounterPoint
+
+	// 1. 起始值: StartBlock 之前的状态
+	before := opts.StartBlock - 1
+	count, err := counter.GetCount(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(before)})
+	if err != nil {
+		Logger().Warn("无法读取初始计数值，时间序列将从第一个事件开始", logBlock(before), logErr(wrapStateError(err, BlockRef{Number: new(big.Int).SetUint64(before)})))
+	} else {
+		ts, err := blockTime(before)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, CounterPoint{Block: before, Timestamp: ts, Count: count.String(), Source: "state"})
+	}
+
+	// 2. 分段查询事件
+	for from := opts.StartBlock; from <= opts.EndBlock; from += logQueryBlockRange {
+		to := min(from+logQueryBlockRange-1, opts.EndBlock)
+		end := to
+		iter, err := counter.FilterCountIncremented(&bind.FilterOpts{Start: from, End: &end, Context: ctx})
+		if err != nil {
+			return nil, fmt.Errorf("查询区块 %d-%d 的事件失败: %v", from, to, err)
+		}
+		for iter.Next() {
+			ev := iter.Event
+			ts, err := blockTime(ev.Raw.BlockNumber)
+			if err != nil {
+				iter.Close()
+				return nil, err
+			}
+			points = append(points, CounterPoint{
+				Block:     ev.Raw.BlockNumber,
+				Timestamp: ts,
+				TxHash:    ev.Raw.TxHash.Hex(),
+				LogIndex:  ev.Raw.Index,
+				Count:     ev.NewCount.String(),
+				Source:    "event",
+			})
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取区块 %d-%d 的事件失败: %v", from, to, err)
+		}
+		Logger().Debug("已扫描区块", "from", from, "to", to, "points", len(points))
+	}
+
+	// 3. 抽查: 在事件所在区块读取历史 GetCount，应等于该区块最后一个事件的 newCount
+	if err := spotCheckCounter(ctx, counter, points, opts.SpotChecks); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+func spotCheckCounter(ctx context.Context, counter *contract.Contract, points []CounterPoint, n int) error {
+	// 每个区块以最后一个事件的值为准
+	lastInBlock := map[uint64]string{}
+	var blocks []uint64
+	for _, p := range points {
+		if p.Source != "event" {
+			continue
+		}
+		if _, ok := lastInBlock[p.Block]; !ok {
+			blocks = append(blocks, p.Block)
+		}
+		lastInBlock[p.Block] = p.Count
+	}
+	if n <= 0 || len(blocks) == 0 {
+		return nil
+	}
+	if n > len(blocks) {
+		n = len(blocks)
+	}
+
+	for i := 0; i < n; i++ {
+		// 均匀抽样，且总是包含最后一个区块
+		idx := len(blocks) - 1
+		if n > 1 {
+			idx = i * (len(blocks) - 1) / (n - 1)
+		}
+		block := blocks[idx]
+		ref := BlockRef{Number: new(big.Int).SetUint64(block)}
+		count, err := counter.GetCount(ref.CallOpts(ctx))
+		if err != nil {
+			err = wrapStateError(err, ref)
+			if errors.Is(err, ErrHistoricalStateUnavailable) {
+				Logger().Warn("节点不支持历史状态读取，跳过抽查", logErr(err))
+				return nil
+			}
+			return fmt.Errorf("抽查区块 %d 失败: %v", block, err)
+		}
+		if count.String() != lastInBlock[block] {
+			return fmt.Errorf("抽查不一致: 区块 %d 事件值为 %s，GetCount 返回 %s", block, lastInBlock[block], count)
+		}
+		Logger().Info("抽查通过", logBlock(block), "count", count)
+	}
+	return nil
+}
+
+// WriteCounterHistory 按文件扩展名 (.json 或 .csv) 导出时间序列
+func WriteCounterHistory(path string, points []CounterPoint) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err := json.MarshalIndent(points, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化时间序列失败: %v", err)
+		}
+		return os.WriteFile(path, append(data, '\n'), 0o644)
+
+	case ".csv", "":
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("创建文件失败: %v", err)
+		}
+		if err := writeCounterHistoryCSV(f, points); err != nil {
+			f.Close()
+			return fmt.Errorf("写入 %s 失败: %v", path, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("写入 %s 失败: %v", path, err)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("不支持的导出格式 %q (仅支持 .csv / .json)", filepath.Ext(path))
+	}
+}
+
+// writeCounterHistoryCSV 写出带表头的时间序列 CSV，任一行写入失败即返回错误
+func writeCounterHistoryCSV(out io.Writer, points []CounterPoint) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"block", "timestamp", "unix", "tx_hash", "log_index", "count", "source"}); err != nil {
+		return err
+	}
+	for _, p := range points {
+		err := w.Write([]string{
+			strconv.FormatUint(p.Block, 10),
+			p.Timestamp.Format(time.RFC3339),
+			strconv.FormatInt(p.Timestamp.Unix(), 10),
+			p.TxHash,
+			strconv.FormatUint(uint64(p.LogIndex), 10),
+			p.Count,
+			p.Source,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,183 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sun-DappBackend-homework/internal/contract"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Create2FactoryAddress 是确定性部署代理 (Arachnid deterministic-deployment-proxy) 的地址。
+// 它在主网、Sepolia 以及 Anvil / Hardhat 本地链上都位于同一地址。
+// 调用数据格式为 salt (32 字节) + 创建代码，返回部署后的合约地址。
+var Create2FactoryAddress = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+// bundledContracts 项目内置、可直接部署的合约
+var bundledContracts = map[string]*bind.MetaData{
+	"Counter": contract.ContractMetaData,
+}
+
+// BundledContractNames 返回内置合约名称列表
+func BundledContractNames() []string {
+	names := make([]string, 0, len(bundledContracts))
+	for name := range bundledContracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseSalt 解析 CREATE2 盐值。
+// 0x 开头的 32 字节十六进制直接使用，其他字符串取 keccak256 作为盐值 (如 "counter-v1")。
+func ParseSalt(s string) (common.Hash, error) {
+	if strings.HasPrefix(s, "0x") {
+		b, err := hexutil.Decode(s)
+		if err != nil || len(b) != common.HashLength {
+			return common.Hash{}, fmt.Errorf("十六进制盐值必须是 32 字节: %s", s)
+		}
+		return common.BytesToHash(b), nil
+	}
+	if s == "" {
+		return common.Hash{}, fmt.Errorf("盐值不能为空")
+	}
+	return crypto.Keccak256Hash([]byte(s)), nil
+}
+
+// ComputeCreate2Address 预先计算通过工厂合约部署后的合约地址
+func ComputeCreate2Address(factory common.Address, salt common.Hash, creationCode []byte) common.Address {
+	return crypto.CreateAddress2(factory, salt, crypto.Keccak256(creationCode))
+}
+
+// DeployContractCreate2 通过 CREATE2 工厂确定性地部署内置合约。
+// 发送前先计算并打印目标地址；若该地址已有代码，则校验代码后直接返回 (不发送交易)。
+// 第二个返回值表示本次是否实际发送了部署交易。
+func DeployContractCreate2(client Client, privateKeyHex string, contractName string, name string, salt common.Hash) (*Deployment, bool, error) {
+	meta, ok := bundledContracts[contractName]
+	if !ok {
+		return nil, false, fmt.Errorf("未知的内置合约 %q (可选: %s)", contractName, strings.Join(BundledContractNames(), ", "))
+	}
+	creationCode := common.FromHex(meta.Bin)
+	parsed, err := meta.GetAbi()
+	if err != nil {
+		return nil, false, fmt.Errorf("解析合约 ABI 失败: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+
+	address := ComputeCreate2Address(Create2FactoryAddress, salt, creationCode)
+	fmt.Fprintf(TextOut(), "CREATE2 工厂:   %s\n", Create2FactoryAddress.Hex())
+	fmt.Fprintf(TextOut(), "盐值:           %s\n", salt.Hex())
+	fmt.Fprintf(TextOut(), "目标合约地址:   %s\n", address.Hex())
+
+	_, deployer, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return nil, false, err
+	}
+
+	deployment := &Deployment{
+		Name:     name,
+		Contract: contractName,
+		Network:  ChainName(chainID),
+		ChainID:  chainID.Uint64(),
+		Address:  address.Hex(),
+		Deployer: deployer.Hex(),
+		Salt:     salt.Hex(),
+		Factory:  Create2FactoryAddress.Hex(),
+	}
+
+	// 目标地址已有代码时不重复部署
+	existing, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("读取目标地址代码失败: %v", err)
+	}
+	if len(existing) > 0 {
+		code, err := verifyDeployedCode(ctx, client, Create2FactoryAddress, address, creationCode, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		fmt.Fprintln(TextOut(), "目标地址已存在相同合约，跳过部署")
+		deployment.BytecodeHash = crypto.Keccak256Hash(code).Hex()
+		deployment.DeployedAt = time.Now().UTC()
+		return deployment, false, nil
+	}
+
+	factoryCode, err := client.CodeAt(ctx, Create2FactoryAddress, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("读取工厂合约代码失败: %v", err)
+	}
+	if len(factoryCode) == 0 {
+		return nil, false, fmt.Errorf("当前网络 (链 ID %s) 上没有部署 CREATE2 工厂 %s", chainID, Create2FactoryAddress.Hex())
+	}
+
+	auth, err := getTransactOpts(client, privateKeyHex)
+	if err != nil {
+		return nil, false, err
+	}
+	auth.GasLimit = 0 // 由 BoundContract 自动估算
+
+	factory := bind.NewBoundContract(Create2FactoryAddress, abi.ABI{}, client, retrySendClient{client}, client)
+	tx, err := factory.RawTransact(auth, append(salt.Bytes(), creationCode...))
+	if err != nil {
+		return nil, false, fmt.Errorf("发送 CREATE2 部署交易失败: %w", DecodeContractError(err, parsed))
+	}
+	fmt.Fprintf(TextOut(), "CREATE2 部署交易已发送。交易哈希: %s\n", tx.Hash().Hex())
+	Logger().Info("正在等待部署交易上链", logTx(tx.Hash()))
+
+	receipt, err := waitForReceipt(ctx, client, tx.Hash())
+	if err != nil {
+		return nil, false, fmt.Errorf("等待部署回执失败 (交易 %s): %v", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, false, fmt.Errorf("CREATE2 部署交易执行失败 (交易 %s, 区块 %d)", tx.Hash().Hex(), receipt.BlockNumber.Uint64())
+	}
+
+	code, err := verifyDeployedCode(ctx, client, Create2FactoryAddress, address, creationCode, receipt.BlockNumber)
+	if err != nil {
+		return nil, false, err
+	}
+
+	deployment.TxHash = tx.Hash().Hex()
+	deployment.Block = receipt.BlockNumber.Uint64()
+	deployment.BytecodeHash = crypto.Keccak256Hash(code).Hex()
+	deployment.DeployedAt = time.Now().UTC()
+	return deployment, true, nil
+}
+
+// PrintCreate2Prediction 打印 CREATE2 部署的目标地址以及该地址当前是否已有代码 (Dry-run)
+func PrintCreate2Prediction(client Client, contractName string, salt common.Hash) error {
+	meta, ok := bundledContracts[contractName]
+	if !ok {
+		return fmt.Errorf("未知的内置合约 %q (可选: %s)", contractName, strings.Join(BundledContractNames(), ", "))
+	}
+	address := ComputeCreate2Address(Create2FactoryAddress, salt, common.FromHex(meta.Bin))
+
+	code, err := client.CodeAt(context.Background(), address, nil)
+	if err != nil {
+		return fmt.Errorf("读取目标地址代码失败: %v", err)
+	}
+	fmt.Fprintf(TextOut(), "CREATE2 工厂:   %s\n", Create2FactoryAddress.Hex())
+	fmt.Fprintf(TextOut(), "盐值:           %s\n", salt.Hex())
+	fmt.Fprintf(TextOut(), "目标合约地址:   %s\n", address.Hex())
+	if len(code) > 0 {
+		fmt.Fprintf(TextOut(), "目标地址已有代码 (%d 字节)，部署将被跳过\n", len(code))
+	} else {
+		fmt.Fprintln(TextOut(), "目标地址尚无代码")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestParseSalt(t *testing.T) {
+	hexSalt := "0x" + strings.Repeat("0", 62) + "2a"
+	tests := []struct {
+		input   string
+		want    common.Hash
+		wantErr bool
+	}{
+		{input: hexSalt, want: common.HexToHash(hexSalt)},
+		{input: "counter-v1", want: crypto.Keccak256Hash([]byte("counter-v1"))},
+		{input: "0X2a", want: crypto.Keccak256Hash([]byte("0X2a"))}, // 只有小写 0x 前缀按十六进制解析
+		{input: "", wantErr: true},
+		{input: "0x2a", wantErr: true},
+		{input: hexSalt + "00", wantErr: true},
+		{input: "0x" + strings.Repeat("zz", 32), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSalt(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSalt(%q) = %s, 期望返回错误", tt.input, got.Hex())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSalt(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSalt(%q) = %s, 期望 %s", tt.input, got.Hex(), tt.want.Hex())
+		}
+	}
+}
+
+func TestComputeCreate2Address(t *testing.T) {
+	// EIP-1014 中的示例
+	tests := []struct {
+		factory string
+		salt    string
+		code    []byte
+		want    string
+	}{
+		{factory: "0x0000000000000000000000000000000000000000", salt: "0x00", code: []byte{0x00}, want: "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"},
+		{factory: "0xdeadbeef00000000000000000000000000000000", salt: "0x00", code: []byte{0x00}, want: "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"},
+		{factory: "0x0000000000000000000000000000000000000000", salt: "0x00", code: []byte{}, want: "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0"},
+	}
+	for _, tt := range tests {
+		got := ComputeCreate2Address(common.HexToAddress(tt.factory), common.HexToHash(tt.salt), tt.code)
+		if got.Hex() != tt.want {
+			t.Errorf("ComputeCreate2Address(%s, %s) = %s, 期望 %s", tt.factory, tt.salt, got.Hex(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// erc20ABI 仅包含本项目用到的 ERC-20 标准方法，以及用于解码日志的 Transfer / Approval 事件
+const erc20ABI = `[
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"owner","type":"address"},{"indexed":true,"name":"spender","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Approval","type":"event"}
+]`
+
+var parsedERC20ABI = mustParseABI(erc20ABI)
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(fmt.Sprintf("解析内置 ABI 失败: %v", err))
+	}
+	return parsed
+}
+
+// ERC20Info 代币的基本信息
+type ERC20Info struct {
+	Address  common.Address
+	Symbol   string
+	Decimals uint8
+}
+
+// GetERC20Info 读取代币的 symbol 和 decimals
+func GetERC20Info(ctx context.Context, client Client, token common.Address) (*ERC20Info, error) {
+	contract := bind.NewBoundContract(token, parsedERC20ABI, client, client, client)
+	opts := &bind.CallOpts{Context: ctx}
+
+	var out []interface{}
+	if err := contract.Call(opts, &out, "decimals"); err != nil {
+		return nil, fmt.Errorf("读取代币 %s decimals 失败: %v", token.Hex(), err)
+	}
+	decimals := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	// symbol 不是必需的，部分旧代币返回 bytes32，读取失败时忽略
+	symbol := ""
+	out = nil
+	if err := contract.Call(opts, &out, "symbol"); err == nil && len(out) > 0 {
+		symbol, _ = out[0].(string)
+	}
+
+	return &ERC20Info{Address: token, Symbol: symbol, Decimals: decimals}, nil
+}
+
+// GetERC20Balance 读取指定账户在指定区块的代币余额 (最小单位)
+func GetERC20Balance(ctx context.Context, client Client, token common.Address, owner common.Address, block BlockRef) (*big.Int, error) {
+	contract := bind.NewBoundContract(token, parsedERC20ABI, client, client, client)
+
+	var out []interface{}
+	if err := contract.Call(block.CallOpts(ctx), &out, "balanceOf", owner); err != nil {
+		return nil, fmt.Errorf("读取代币 %s 余额失败: %w", token.Hex(), wrapStateError(err, block))
+	}
+	return abi.ConvertType(out[0], new(big.Int)).(*big.Int), nil
+}
+
+// GetERC20Balances 通过 Multicall3 在同一区块批量读取多个 (代币, 账户) 的余额。
+// 单个读取失败时对应位置为 nil，错误记录在 errs 中。
+func GetERC20Balances(ctx context.Context, client Client, tokens []common.Address, owners []common.Address, blockNumber *big.Int) (balances []*big.Int, errs []error, err error) {
+	if len(tokens) != len(owners) {
+		return nil, nil, fmt.Errorf("代币与账户数量不一致")
+	}
+
+	calls := make([]MulticallCall, len(tokens))
+	for i := range tokens {
+		calls[i] = MulticallCall{Target: tokens[i], ABI: &parsedERC20ABI, Method: "balanceOf", Args: []interface{}{owners[i]}}
+	}
+	results, _, err := NewMulticaller(client).Aggregate(ctx, calls, blockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balances = make([]*big.Int, len(results))
+	errs = make([]error, len(results))
+	for i, r := range results {
+		if !r.Success {
+			errs[i] = r.Err
+			continue
+		}
+		balances[i] = r.Values[0].(*big.Int)
+	}
+	return balances, errs, nil
+}
+
+// packERC20Transfer 编码 transfer(to, value) 调用数据
+func packERC20Transfer(to common.Address, value *big.Int) ([]byte, error) {
+	return parsedERC20ABI.Pack("transfer", to, value)
+}
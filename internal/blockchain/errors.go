@@ -0,0 +1,182 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 合约失败的类型
+const (
+	RevertError   = "Error"   // require / revert("reason") 产生的 Error(string)
+	RevertPanic   = "Panic"   // assert、溢出、除零等产生的 Panic(uint256)
+	RevertCustom  = "Custom"  // Solidity 自定义错误 (error Foo(...))
+	RevertUnknown = "Unknown" // 有 revert 数据但无法解码
+	RevertEmpty   = "Empty"   // 无 revert 数据 (如 revert() 或 require(cond) 不带原因)
+)
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// panicReasons Solidity Panic(uint256) 错误码的含义
+var panicReasons = map[uint64]string{
+	0x00: "通用编译器 panic",
+	0x01: "assert 断言失败",
+	0x11: "算术运算溢出或下溢",
+	0x12: "除以零或对零取模",
+	0x21: "转换为枚举时值越界",
+	0x22: "存储字节数组编码错误",
+	0x31: "对空数组执行 pop()",
+	0x32: "数组越界访问",
+	0x41: "分配内存过多或数组过大",
+	0x51: "调用未初始化的内部函数",
+}
+
+// ContractError 是合约调用或交易执行失败时的结构化错误。
+// 可通过 errors.As 从包装后的错误中取出。
+type ContractError struct {
+	Kind      string        // 失败类型，见 RevertError 等常量
+	Reason    string        // Error(string) 的原因文本
+	PanicCode *big.Int      // Panic(uint256) 的错误码
+	Name      string        // 自定义错误名
+	Signature string        // 自定义错误签名，如 "InsufficientBalance(uint256,uint256)"
+	Args      []interface{} // 自定义错误参数 (已解码)
+	ArgNames  []string      // 自定义错误参数名
+	Data      []byte        // 原始 revert 数据
+	Err       error         // 原始 RPC 错误
+}
+
+func (e *ContractError) Error() string {
+	switch e.Kind {
+	case RevertError:
+		return fmt.Sprintf("执行回滚: %s", e.Reason)
+	case RevertPanic:
+		return fmt.Sprintf("执行 panic: 0x%x (%s)", e.PanicCode, PanicReason(e.PanicCode))
+	case RevertCustom:
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = fmt.Sprintf("%s=%v", e.ArgNames[i], arg)
+		}
+		return fmt.Sprintf("执行回滚: 自定义错误 %s(%s)", e.Name, strings.Join(args, ", "))
+	case RevertUnknown:
+		return fmt.Sprintf("执行回滚: 无法解码的错误数据 %s", hexutil.Encode(e.Data))
+	default:
+		if e.Err != nil {
+			return fmt.Sprintf("执行回滚 (无原因): %v", e.Err)
+		}
+		return "执行回滚 (无原因)"
+	}
+}
+
+func (e *ContractError) Unwrap() error {
+	return e.Err
+}
+
+// PanicReason 返回 Panic 错误码的可读说明
+func PanicReason(code *big.Int) string {
+	if code != nil && code.IsUint64() {
+		if reason, ok := panicReasons[code.Uint64()]; ok {
+			return reason
+		}
+	}
+	return "未知 panic 错误码"
+}
+
+// DecodeRevertData 解码 revert 数据。
+// 依次尝试 Error(string)、Panic(uint256)，再在提供的 ABI 中查找自定义错误。
+func DecodeRevertData(data []byte, abis ...*abi.ABI) *ContractError {
+	ce := &ContractError{Data: data}
+	if len(data) == 0 {
+		ce.Kind = RevertEmpty
+		return ce
+	}
+	if len(data) < 4 {
+		ce.Kind = RevertUnknown
+		return ce
+	}
+
+	selector := data[:4]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			ce.Kind = RevertError
+			ce.Reason = reason
+			return ce
+		}
+	case bytes.Equal(selector, panicSelector):
+		if len(data) == 4+32 {
+			ce.Kind = RevertPanic
+			ce.PanicCode = new(big.Int).SetBytes(data[4:])
+			return ce
+		}
+	}
+
+	var id [4]byte
+	copy(id[:], selector)
+	for _, contractABI := range abis {
+		if contractABI == nil {
+			continue
+		}
+		abiErr, err := contractABI.ErrorByID(id)
+		if err != nil {
+			continue
+		}
+		values, err := abiErr.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		ce.Kind = RevertCustom
+		ce.Name = abiErr.Name
+		ce.Signature = abiErr.Sig
+		for i, input := range abiErr.Inputs {
+			name := input.Name
+			if name == "" {
+				name = fmt.Sprintf("arg%d", i)
+			}
+			ce.ArgNames = append(ce.ArgNames, name)
+			ce.Args = append(ce.Args, NormalizeABIValue(input.Type, values[i]))
+		}
+		return ce
+	}
+
+	ce.Kind = RevertUnknown
+	return ce
+}
+
+// DecodeContractError 从 RPC 错误中提取 revert 数据并解码为 *ContractError。
+// 错误中不含 revert 信息时 (如网络错误) 原样返回。
+func DecodeContractError(err error, abis ...*abi.ABI) error {
+	if err == nil {
+		return nil
+	}
+	var ce *ContractError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if s, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(s); decodeErr == nil {
+				ce = DecodeRevertData(data, abis...)
+				ce.Err = err
+				return ce
+			}
+		}
+	}
+
+	// 部分节点只返回 "execution reverted" 而不附带数据
+	if strings.Contains(err.Error(), "execution reverted") {
+		return &ContractError{Kind: RevertEmpty, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const testErrorsABI = `[
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]},
+	{"type":"error","name":"Unauthorized","inputs":[{"name":"","type":"address"}]}
+]`
+
+// testDataError 模拟节点返回的带 revert 数据的 JSON-RPC 错误
+type testDataError struct {
+	data interface{}
+}
+
+func (e testDataError) Error() string          { return "execution reverted" }
+func (e testDataError) ErrorData() interface{} { return e.data }
+
+func packRevert(t *testing.T, sig string, types []string, args ...interface{}) []byte {
+	t.Helper()
+	var arguments abi.Arguments
+	for _, name := range types {
+		typ, err := abi.NewType(name, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		arguments = append(arguments, abi.Argument{Type: typ})
+	}
+	packed, err := arguments.Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(selectorOf(sig), packed...)
+}
+
+// selectorOf 返回 Error / Panic 或 testErrorsABI 中错误签名的 4 字节选择器
+func selectorOf(sig string) []byte {
+	switch sig {
+	case "Error(string)":
+		return append([]byte(nil), errorSelector...)
+	case "Panic(uint256)":
+		return append([]byte(nil), panicSelector...)
+	}
+	parsed, _ := abi.JSON(strings.NewReader(testErrorsABI))
+	for _, e := range parsed.Errors {
+		if e.Sig == sig {
+			return e.ID[:4]
+		}
+	}
+	panic("unknown signature " + sig)
+}
+
+func TestDecodeRevertData(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testErrorsABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+
+	tests := []struct {
+		name      string
+		data      []byte
+		abis      []*abi.ABI
+		kind      string
+		wantError string
+	}{
+		{name: "空数据", data: nil, kind: RevertEmpty, wantError: "执行回滚 (无原因)"},
+		{name: "不足 4 字节", data: []byte{1, 2}, kind: RevertUnknown, wantError: "0x0102"},
+		{name: "Error(string)", data: packRevert(t, "Error(string)", []string{"string"}, "余额不足"), kind: RevertError, wantError: "执行回滚: 余额不足"},
+		{name: "Panic 溢出", data: packRevert(t, "Panic(uint256)", []string{"uint256"}, big.NewInt(0x11)), kind: RevertPanic, wantError: "0x11 (算术运算溢出或下溢)"},
+		{name: "未知 Panic 错误码", data: packRevert(t, "Panic(uint256)", []string{"uint256"}, big.NewInt(0x99)), kind: RevertPanic, wantError: "未知 panic 错误码"},
+		{name: "Panic 数据长度错误", data: append(selectorOf("Panic(uint256)"), 1), kind: RevertUnknown},
+		{name: "Error 数据损坏", data: append(selectorOf("Error(string)"), 0xff), kind: RevertUnknown},
+		{
+			name: "自定义错误", abis: []*abi.ABI{nil, &parsed}, kind: RevertCustom,
+			data:      packRevert(t, "InsufficientBalance(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(5), big.NewInt(10)),
+			wantError: "自定义错误 InsufficientBalance(available=5, required=10)",
+		},
+		{
+			name: "自定义错误无参数名", abis: []*abi.ABI{&parsed}, kind: RevertCustom,
+			data:      packRevert(t, "Unauthorized(address)", []string{"address"}, owner),
+			wantError: "Unauthorized(arg0=" + owner.Hex() + ")",
+		},
+		{
+			name: "未提供 ABI 的自定义错误", kind: RevertUnknown,
+			data: packRevert(t, "InsufficientBalance(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(5), big.NewInt(10)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := DecodeRevertData(tt.data, tt.abis...)
+			if ce.Kind != tt.kind {
+				t.Fatalf("Kind = %s, 期望 %s (%v)", ce.Kind, tt.kind, ce)
+			}
+			if tt.wantError != "" && !strings.Contains(ce.Error(), tt.wantError) {
+				t.Errorf("Error() = %q, 期望包含 %q", ce.Error(), tt.wantError)
+			}
+		})
+	}
+}
+
+func TestDecodeContractError(t *testing.T) {
+	data := packRevert(t, "Error(string)", []string{"string"}, "not owner")
+	rpcErr := fmt.Errorf("估算 Gas 失败: %w", testDataError{data: hexutil.Encode(data)})
+
+	var ce *ContractError
+	if err := DecodeContractError(rpcErr); !errors.As(err, &ce) || ce.Kind != RevertError || ce.Reason != "not owner" {
+		t.Fatalf("DecodeContractError = %v, 期望 Error(string) not owner", err)
+	}
+	if ce.Err != rpcErr {
+		t.Error("ContractError 应保留原始错误")
+	}
+
+	if err := DecodeContractError(errors.New("execution reverted")); !errors.As(err, &ce) || ce.Kind != RevertEmpty {
+		t.Errorf("不带数据的 execution reverted 应解码为 Empty，得到 %v", err)
+	}
+
+	network := errors.New("connection refused")
+	if err := DecodeContractError(network); err != network {
+		t.Errorf("网络错误应原样返回，得到 %v", err)
+	}
+	if DecodeContractError(nil) != nil {
+		t.Error("nil 应返回 nil")
+	}
+}
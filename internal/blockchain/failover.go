@@ -0,0 +1,327 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// 节点健康检查参数
+const (
+	healthCheckInterval = 15 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+	maxEndpointBlockLag = 5               // 落后最高节点超过该区块数视为不健康
+	maxEndpointLatency  = 3 * time.Second // 健康检查延迟超过该值视为不健康
+)
+
+// EndpointStatus 单个 RPC 节点的健康状态
+type EndpointStatus struct {
+	URL       string        `json:"url"` // 已隐藏路径中的 API Key
+	Healthy   bool          `json:"healthy"`
+	Active    bool          `json:"active"`
+	Height    uint64        `json:"height"`
+	Latency   time.Duration `json:"latencyNs"`
+	Failures  int           `json:"failures"` // 连续失败次数
+	LastError string        `json:"lastError,omitempty"`
+	LastCheck time.Time     `json:"lastCheck"`
+}
+
+func (s *EndpointStatus) CSVHeader() []string {
+	return []string{"url", "healthy", "active", "height", "latency_ms", "failures", "last_error", "last_check"}
+}
+
+func (s *EndpointStatus) CSVRow() []string {
+	return []string{s.URL, fmt.Sprint(s.Healthy), fmt.Sprint(s.Active), u64(s.Height), fmt.Sprint(s.Latency.Milliseconds()),
+		fmt.Sprint(s.Failures), s.LastError, s.LastCheck.Format(time.RFC3339)}
+}
+
+type endpoint struct {
+	url    *url.URL
+	status EndpointStatus
+}
+
+// FailoverTransport 按优先级在多个 HTTP RPC 节点之间转发请求的 http.RoundTripper。
+// 每个请求优先发往优先级最高的健康节点；网络错误、5xx 或 429 时依次尝试下一个节点。
+// 后台健康检查定期比较各节点的区块高度和延迟，主节点恢复后自动切回。
+type FailoverTransport struct {
+	base      http.RoundTripper
+	mu        sync.Mutex
+	endpoints []*endpoint // 按优先级排列
+	active    int
+}
+
+// NewFailoverTransport 创建故障转移传输层，urls 按优先级排列且必须为 http(s) 地址
+func NewFailoverTransport(urls []string) (*FailoverTransport, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("未配置 RPC 节点")
+	}
+	t := &FailoverTransport{base: http.DefaultTransport}
+	for _, raw := range urls {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("无效的 HTTP RPC 地址: %s", redactURL(raw))
+		}
+		t.endpoints = append(t.endpoints, &endpoint{url: u, status: EndpointStatus{URL: redactURL(raw), Healthy: true}})
+	}
+	return t, nil
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *FailoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 请求体需要在切换节点时重放
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var lastErr error
+	for _, i := range t.order() {
+		ep := t.endpoints[i]
+		out := req.Clone(req.Context())
+		out.URL = ep.url
+		out.Host = ep.url.Host
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+
+		resp, err := t.base.RoundTrip(out)
+		if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			t.markSuccess(i)
+			return resp, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("HTTP %s", resp.Status)
+			resp.Body.Close()
+		}
+		if req.Context().Err() != nil {
+			return nil, req.Context().Err()
+		}
+		// 积分预算耗尽与节点无关，切换节点也无法恢复
+		if errors.Is(err, ErrCreditBudgetExceeded) {
+			return nil, err
+		}
+		t.markFailure(i, err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("所有 RPC 节点均不可用: %v", lastErr)
+}
+
+// order 返回本次请求尝试节点的顺序：健康节点按优先级在前，不健康的节点作为最后手段
+func (t *FailoverTransport) order() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var healthy, unhealthy []int
+	for i, ep := range t.endpoints {
+		if ep.status.Healthy {
+			healthy = append(healthy, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (t *FailoverTransport) markSuccess(i int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ep := t.endpoints[i]
+	ep.status.Failures = 0
+	if t.active != i {
+		Logger().Warn("RPC 节点切换", "from", t.endpoints[t.active].status.URL, logEndpoint(ep.status.URL))
+		t.active = i
+	}
+}
+
+func (t *FailoverTransport) markFailure(i int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ep := t.endpoints[i]
+	ep.status.Failures++
+	ep.status.LastError = err.Error()
+	if ep.status.Healthy {
+		Logger().Warn("RPC 节点请求失败，暂时标记为不健康", logEndpoint(ep.status.URL), logErr(err))
+	}
+	ep.status.Healthy = false
+}
+
+// CheckHealth 并发检查所有节点的区块高度与延迟，并据此更新健康状态
+func (t *FailoverTransport) CheckHealth(ctx context.Context) {
+	type result struct {
+		height  uint64
+		latency time.Duration
+		err     error
+	}
+	results := make([]result, len(t.endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range t.endpoints {
+		wg.Add(1)
+		go func(i int, u *url.URL) {
+			defer wg.Done()
+			start := time.Now()
+			height, err := t.blockNumber(ctx, u)
+			results[i] = result{height: height, latency: time.Since(start), err: err}
+		}(i, ep.url)
+	}
+	wg.Wait()
+
+	var best uint64
+	for _, r := range results {
+		if r.err == nil && r.height > best {
+			best = r.height
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, ep := range t.endpoints {
+		r := results[i]
+		s := &ep.status
+		wasHealthy := s.Healthy
+		s.LastCheck = time.Now()
+		s.Latency = r.latency
+		switch {
+		case r.err != nil:
+			s.Healthy = false
+			s.Failures++
+			s.LastError = r.err.Error()
+		case best-r.height > maxEndpointBlockLag:
+			s.Healthy = false
+			s.Height = r.height
+			s.LastError = fmt.Sprintf("落后最高节点 %d 个区块", best-r.height)
+		case r.latency > maxEndpointLatency:
+			s.Healthy = false
+			s.Height = r.height
+			s.LastError = fmt.Sprintf("延迟过高 (%s)", r.latency.Round(time.Millisecond))
+		default:
+			s.Healthy = true
+			s.Height = r.height
+			s.Failures = 0
+			s.LastError = ""
+		}
+		if wasHealthy != s.Healthy {
+			if s.Healthy {
+				Logger().Info("RPC 节点已恢复", logEndpoint(s.URL), logBlock(s.Height))
+			} else {
+				Logger().Warn("RPC 节点不健康", logEndpoint(s.URL), slog.String(LogKeyError, s.LastError))
+			}
+		}
+	}
+}
+
+// Run 定期执行健康检查，直到 ctx 取消
+func (t *FailoverTransport) Run(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.CheckHealth(ctx)
+		}
+	}
+}
+
+// Status 返回各节点当前状态 (按优先级排列)
+func (t *FailoverTransport) Status() []EndpointStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]EndpointStatus, len(t.endpoints))
+	for i, ep := range t.endpoints {
+		statuses[i] = ep.status
+		statuses[i].Active = i == t.active
+	}
+	return statuses
+}
+
+// blockNumber 直接向单个节点发送 eth_blockNumber，不经过故障转移
+func (t *FailoverTransport) blockNumber(ctx context.Context, u *url.URL) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	payload := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %s", resp.Status)
+	}
+
+	var out struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("解析响应失败: %v", err)
+	}
+	if out.Error != nil {
+		return 0, fmt.Errorf("%s", out.Error.Message)
+	}
+	return hexutil.DecodeUint64(out.Result)
+}
+
+// dialWebSocket 按优先级依次连接逗号分隔的 WebSocket 地址，返回第一个成功且链 ID 与 chainID 一致的连接 (chainID 为 0 时不校验)。
+// 每次重连都从最高优先级开始，因此主节点恢复后会在下次重连时自动切回。
+// 任一地址链 ID 不匹配且没有可用连接时，返回的错误包含 ErrChainIDMismatch (配置错误，重连无法恢复)。
+func dialWebSocket(ctx context.Context, wsURLs string, chainID uint64) (*ethclient.Client, error) {
+	var lastErr error
+	for _, u := range strings.Split(wsURLs, ",") {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		client, err := ethclient.DialContext(ctx, u)
+		if err == nil {
+			if _, err = VerifyChainID(ctx, client, chainID); err == nil {
+				Logger().Info("已连接 WebSocket 节点", logEndpoint(u))
+				return client, nil
+			}
+			client.Close()
+		}
+		Logger().Warn("连接 WebSocket 节点失败", logEndpoint(u), logErr(err))
+		if lastErr == nil || !errors.Is(lastErr, ErrChainIDMismatch) {
+			lastErr = err
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("未配置 WebSocket 地址")
+	}
+	return nil, lastErr
+}
+
+// redactURL 隐藏 URL 路径和查询参数 (通常包含 API Key)，用于日志输出
+func redactURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "(无效地址)"
+	}
+	if u.Path == "" || u.Path == "/" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
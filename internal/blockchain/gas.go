@@ -0,0 +1,244 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultGasHistoryBlocks gas 模式默认分析的区块数
+const DefaultGasHistoryBlocks = 20
+
+// maxGasHistoryBlocks eth_feeHistory 单次可查询的最大区块数 (多数节点限制为 1024)
+const maxGasHistoryBlocks = 1024
+
+// gasPercentiles 请求的优先费百分位，依次对应慢 / 标准 / 快
+var gasPercentiles = []float64{10, 50, 90}
+
+// gasSpeeds 推荐费用档位。maxFeePerGas = 下一区块基础费用 * BaseFeePercent / 100 + 优先费：
+// 基础费用每个区块最多上涨 12.5%，档位越快预留的上涨空间越大 (快档与 SendTransaction 的 2 倍一致)。
+var gasSpeeds = []struct {
+	Name           string
+	Label          string
+	BaseFeePercent int64
+}{
+	{"slow", "慢", 100},
+	{"normal", "标准", 125},
+	{"fast", "快", 200},
+}
+
+// 基础费用变化超过该百分比时视为上涨或下跌
+const gasTrendThreshold = 5.0
+
+// GasRecommendation 某一档位的推荐费用 (wei)
+type GasRecommendation struct {
+	Speed                string `json:"speed"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+}
+
+// PercentileFee 某一百分位的优先费 (各区块该百分位的中位数，wei)
+type PercentileFee struct {
+	Percentile float64 `json:"percentile"`
+	Fee        string  `json:"fee"`
+}
+
+// GasReport 基于 eth_feeHistory 的 Gas 市场分析结果，费用均为 wei
+type GasReport struct {
+	OldestBlock     uint64              `json:"oldestBlock"`
+	LatestBlock     uint64              `json:"latestBlock"`
+	Blocks          int                 `json:"blocks"`
+	BaseFeeMin      string              `json:"baseFeeMin"`
+	BaseFeeAvg      string              `json:"baseFeeAvg"`
+	BaseFeeMax      string              `json:"baseFeeMax"`
+	BaseFeeLatest   string              `json:"baseFeeLatest"`
+	BaseFeeNext     string              `json:"baseFeeNext"`
+	BaseFeeChange   float64             `json:"baseFeeChange"` // 最新区块相对最早区块的变化百分比
+	Trend           string              `json:"trend"`         // rising / falling / stable
+	GasUsedRatio    float64             `json:"gasUsedRatio"`  // 平均区块使用率 (0-1)
+	FullBlocks      int                 `json:"fullBlocks"`    // 使用率超过 95% 的区块数
+	PriorityFees    []PercentileFee     `json:"priorityFees"`
+	Recommendations []GasRecommendation `json:"recommendations"`
+}
+
+func (r *GasReport) CSVHeader() []string {
+	header := []string{"latest_block", "blocks", "base_fee_latest", "base_fee_next", "base_fee_avg", "base_fee_change", "trend", "gas_used_ratio"}
+	for _, speed := range gasSpeeds {
+		header = append(header, speed.Name+"_max_priority_fee", speed.Name+"_max_fee")
+	}
+	return header
+}
+
+func (r *GasReport) CSVRow() []string {
+	row := []string{u64(r.LatestBlock), strconv.Itoa(r.Blocks), r.BaseFeeLatest, r.BaseFeeNext, r.BaseFeeAvg,
+		strconv.FormatFloat(r.BaseFeeChange, 'f', 2, 64), r.Trend, strconv.FormatFloat(r.GasUsedRatio, 'f', 4, 64)}
+	for _, rec := range r.Recommendations {
+		row = append(row, rec.MaxPriorityFeePerGas, rec.MaxFeePerGas)
+	}
+	return row
+}
+
+// GetGasReport 分析截至 lastBlock (nil 表示最新) 的最近 blocks 个区块的费用数据
+func GetGasReport(ctx context.Context, client Client, blocks int, lastBlock *big.Int) (*GasReport, error) {
+	if blocks <= 0 || blocks > maxGasHistoryBlocks {
+		return nil, fmt.Errorf("分析区块数必须在 1-%d 之间", maxGasHistoryBlocks)
+	}
+	history, err := client.FeeHistory(ctx, uint64(blocks), lastBlock, gasPercentiles)
+	if err != nil {
+		return nil, fmt.Errorf("获取费用历史失败: %v", err)
+	}
+	n := len(history.GasUsedRatio)
+	if n == 0 || len(history.BaseFee) < n+1 {
+		return nil, fmt.Errorf("节点返回的费用历史为空")
+	}
+	if history.BaseFee[0] == nil || history.BaseFee[0].Sign() == 0 {
+		return nil, fmt.Errorf("当前网络不支持 EIP-1559 (基础费用为空)")
+	}
+
+	oldest := history.OldestBlock.Uint64()
+	report := &GasReport{
+		OldestBlock: oldest,
+		LatestBlock: oldest + uint64(n) - 1,
+		Blocks:      n,
+	}
+
+	// 1. 基础费用: BaseFee 比区块多一项，最后一项为下一区块的基础费用
+	baseFees := history.BaseFee[:n]
+	sum := new(big.Int)
+	minFee, maxFee := baseFees[0], baseFees[0]
+	for _, fee := range baseFees {
+		sum.Add(sum, fee)
+		if fee.Cmp(minFee) < 0 {
+			minFee = fee
+		}
+		if fee.Cmp(maxFee) > 0 {
+			maxFee = fee
+		}
+	}
+	latest, next := baseFees[n-1], history.BaseFee[n]
+	report.BaseFeeMin = minFee.String()
+	report.BaseFeeMax = maxFee.String()
+	report.BaseFeeAvg = sum.Div(sum, big.NewInt(int64(n))).String()
+	report.BaseFeeLatest = latest.String()
+	report.BaseFeeNext = next.String()
+	change, _ := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Sub(latest, baseFees[0])), new(big.Float).SetInt(baseFees[0])).Float64()
+	report.BaseFeeChange = change * 100
+	switch {
+	case report.BaseFeeChange > gasTrendThreshold:
+		report.Trend = "rising"
+	case report.BaseFeeChange < -gasTrendThreshold:
+		report.Trend = "falling"
+	default:
+		report.Trend = "stable"
+	}
+
+	// 2. 区块使用率
+	var ratioSum float64
+	for _, ratio := range history.GasUsedRatio {
+		ratioSum += ratio
+		if ratio > 0.95 {
+			report.FullBlocks++
+		}
+	}
+	report.GasUsedRatio = ratioSum / float64(n)
+
+	// 3. 优先费: 对每个百分位取各区块的中位数，空区块的奖励恒为 0，不参与统计
+	tips := make([]*big.Int, len(gasPercentiles))
+	for p := range gasPercentiles {
+		var samples []*big.Int
+		for i, rewards := range history.Reward {
+			if history.GasUsedRatio[i] > 0 && p < len(rewards) && rewards[p] != nil {
+				samples = append(samples, rewards[p])
+			}
+		}
+		tips[p] = medianBig(samples)
+		report.PriorityFees = append(report.PriorityFees, PercentileFee{Percentile: gasPercentiles[p], Fee: tips[p].String()})
+	}
+
+	// 4. 推荐费用
+	for i, speed := range gasSpeeds {
+		feeCap := new(big.Int).Mul(next, big.NewInt(speed.BaseFeePercent))
+		feeCap.Div(feeCap, big.NewInt(100))
+		feeCap.Add(feeCap, tips[i])
+		report.Recommendations = append(report.Recommendations, GasRecommendation{
+			Speed:                speed.Name,
+			MaxPriorityFeePerGas: tips[i].String(),
+			MaxFeePerGas:         feeCap.String(),
+		})
+	}
+	return report, nil
+}
+
+// medianBig 返回中位数，样本为空时返回 0
+func medianBig(values []*big.Int) *big.Int {
+	if len(values) == 0 {
+		return new(big.Int)
+	}
+	sorted := slices.Clone(values)
+	slices.SortFunc(sorted, func(a, b *big.Int) int { return a.Cmp(b) })
+	return new(big.Int).Set(sorted[len(sorted)/2])
+}
+
+var trendLabels = map[string]string{"rising": "上涨", "falling": "下跌", "stable": "平稳"}
+
+// PrintGasReport 打印 Gas 市场分析；非 text 格式时输出 GasReport 记录
+func PrintGasReport(r *GasReport) error {
+	if !IsTextOutput() {
+		return Emit(r)
+	}
+
+	fmt.Fprintln(TextOut(), "==================================================")
+	fmt.Fprintf(TextOut(), "分析区块:   %d - %d (%d 个)\n", r.OldestBlock, r.LatestBlock, r.Blocks)
+	fmt.Fprintf(TextOut(), "基础费用:   最新 %s Gwei，下一区块 %s Gwei\n", formatWeiString(r.BaseFeeLatest, FormatGwei), formatWeiString(r.BaseFeeNext, FormatGwei))
+	fmt.Fprintf(TextOut(), "            最低 %s / 平均 %s / 最高 %s Gwei\n",
+		formatWeiString(r.BaseFeeMin, FormatGwei), formatWeiString(r.BaseFeeAvg, FormatGwei), formatWeiString(r.BaseFeeMax, FormatGwei))
+	fmt.Fprintf(TextOut(), "趋势:       %s (%+.2f%%)\n", trendLabels[r.Trend], r.BaseFeeChange)
+	fmt.Fprintf(TextOut(), "区块使用率: 平均 %.1f%%，%d 个区块接近满载\n", r.GasUsedRatio*100, r.FullBlocks)
+	fmt.Fprint(TextOut(), "优先费:    ")
+	for _, p := range r.PriorityFees {
+		fmt.Fprintf(TextOut(), " P%.0f %s Gwei", p.Percentile, formatWeiString(p.Fee, FormatGwei))
+	}
+	fmt.Fprintln(TextOut())
+	fmt.Fprintln(TextOut(), "--------------------------------------------------")
+	fmt.Fprintln(TextOut(), "推荐费用 (maxPriorityFeePerGas / maxFeePerGas):")
+	transferGas := big.NewInt(21000)
+	for i, rec := range r.Recommendations {
+		maxFee, _ := new(big.Int).SetString(rec.MaxFeePerGas, 10)
+		fmt.Fprintf(TextOut(), "  %s: %s / %s Gwei (普通转账最多 %s ETH)\n", gasSpeeds[i].Label,
+			formatWeiString(rec.MaxPriorityFeePerGas, FormatGwei), formatWeiString(rec.MaxFeePerGas, FormatGwei),
+			FormatEther(new(big.Int).Mul(maxFee, transferGas)))
+	}
+	fmt.Fprintln(TextOut(), "==================================================")
+	return nil
+}
+
+// printGasSummary watch 模式下每个新区块打印一行摘要
+func printGasSummary(r *GasReport) {
+	if !IsTextOutput() {
+		emitOrWarn(r)
+		return
+	}
+	fmt.Fprintf(TextOut(), "区块 %d | 基础费用 %s Gwei (%s %+.1f%%) | 使用率 %.0f%% | 慢 %s / 标准 %s / 快 %s Gwei\n",
+		r.LatestBlock, formatWeiString(r.BaseFeeNext, FormatGwei), trendLabels[r.Trend], r.BaseFeeChange, r.GasUsedRatio*100,
+		formatWeiString(r.Recommendations[0].MaxFeePerGas, FormatGwei),
+		formatWeiString(r.Recommendations[1].MaxFeePerGas, FormatGwei),
+		formatWeiString(r.Recommendations[2].MaxFeePerGas, FormatGwei))
+}
+
+// WatchGas 订阅新区块，每个新区块到来时重新分析最近 blocks 个区块的费用数据
+func WatchGas(ctx context.Context, wsURL string, chainID uint64, blocks int, limiter *RateLimiter) {
+	WatchNewHeads(ctx, wsURL, chainID, 0, limiter, func(client Client, h *types.Header) {
+		report, err := GetGasReport(ctx, client, blocks, h.Number)
+		if err != nil {
+			if ctx.Err() == nil {
+				Logger().Warn("费用分析失败", logBlock(h.Number.Uint64()), logErr(err))
+			}
+			return
+		}
+		printGasSummary(report)
+	})
+}
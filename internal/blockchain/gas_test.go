@@ -0,0 +1,119 @@
+package blockchain
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// feeHistoryClient 返回固定 eth_feeHistory 结果的模拟节点
+type feeHistoryClient struct {
+	Client
+	history *ethereum.FeeHistory
+}
+
+func (c *feeHistoryClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return c.history, nil
+}
+
+func bigs(values ...int64) []*big.Int {
+	out := make([]*big.Int, len(values))
+	for i, v := range values {
+		out[i] = big.NewInt(v)
+	}
+	return out
+}
+
+func TestGetGasReport(t *testing.T) {
+	client := &feeHistoryClient{history: &ethereum.FeeHistory{
+		OldestBlock:  big.NewInt(10),
+		BaseFee:      bigs(100, 110, 120, 130), // 最后一项为下一区块
+		GasUsedRatio: []float64{0.5, 0, 0.99},
+		Reward:       [][]*big.Int{bigs(1, 2, 3), bigs(0, 0, 0), bigs(5, 6, 7)},
+	}}
+	r, err := GetGasReport(context.Background(), client, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.OldestBlock != 10 || r.LatestBlock != 12 || r.Blocks != 3 {
+		t.Errorf("区块范围 = %d-%d (%d 个)，期望 10-12 (3 个)", r.OldestBlock, r.LatestBlock, r.Blocks)
+	}
+	if r.BaseFeeMin != "100" || r.BaseFeeAvg != "110" || r.BaseFeeMax != "120" || r.BaseFeeLatest != "120" || r.BaseFeeNext != "130" {
+		t.Errorf("基础费用统计错误: %+v", r)
+	}
+	if math.Abs(r.BaseFeeChange-20) > 1e-9 || r.Trend != "rising" {
+		t.Errorf("基础费用变化 = %.2f%% (%s)，期望 20%% (rising)", r.BaseFeeChange, r.Trend)
+	}
+	if math.Abs(r.GasUsedRatio-1.49/3) > 1e-9 || r.FullBlocks != 1 {
+		t.Errorf("区块使用率 = %.4f，满块 %d 个，期望 %.4f、1 个", r.GasUsedRatio, r.FullBlocks, 1.49/3)
+	}
+
+	// 空区块 (使用率为 0) 的奖励不参与统计；偶数个样本取较大的中间值
+	wantTips := []string{"5", "6", "7"}
+	for i, fee := range r.PriorityFees {
+		if fee.Fee != wantTips[i] {
+			t.Errorf("第 %v 百分位优先费 = %s，期望 %s", fee.Percentile, fee.Fee, wantTips[i])
+		}
+	}
+
+	// maxFee = 下一区块基础费用 * 档位百分比 / 100 + 优先费
+	want := []GasRecommendation{
+		{Speed: "slow", MaxPriorityFeePerGas: "5", MaxFeePerGas: "135"},
+		{Speed: "normal", MaxPriorityFeePerGas: "6", MaxFeePerGas: "168"},
+		{Speed: "fast", MaxPriorityFeePerGas: "7", MaxFeePerGas: "267"},
+	}
+	for i, rec := range r.Recommendations {
+		if rec != want[i] {
+			t.Errorf("推荐费用[%d] = %+v，期望 %+v", i, rec, want[i])
+		}
+	}
+}
+
+func TestGetGasReportTrend(t *testing.T) {
+	tests := []struct {
+		first, latest int64
+		want          string
+	}{
+		{100, 106, "rising"},
+		{100, 105, "stable"},
+		{100, 95, "stable"},
+		{100, 94, "falling"},
+	}
+	for _, tt := range tests {
+		client := &feeHistoryClient{history: &ethereum.FeeHistory{
+			OldestBlock:  big.NewInt(1),
+			BaseFee:      bigs(tt.first, tt.latest, tt.latest),
+			GasUsedRatio: []float64{0.5, 0.5},
+			Reward:       [][]*big.Int{bigs(1, 1, 1), bigs(1, 1, 1)},
+		}}
+		r, err := GetGasReport(context.Background(), client, 2, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Trend != tt.want {
+			t.Errorf("基础费用 %d -> %d: 趋势 = %s，期望 %s", tt.first, tt.latest, r.Trend, tt.want)
+		}
+	}
+}
+
+func TestGetGasReportErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  int
+		history *ethereum.FeeHistory
+	}{
+		{"区块数为 0", 0, nil},
+		{"区块数超出上限", maxGasHistoryBlocks + 1, nil},
+		{"费用历史为空", 1, &ethereum.FeeHistory{OldestBlock: big.NewInt(1)}},
+		{"不支持 EIP-1559", 1, &ethereum.FeeHistory{OldestBlock: big.NewInt(1), BaseFee: bigs(0, 0), GasUsedRatio: []float64{0.5}}},
+	}
+	for _, tt := range tests {
+		if _, err := GetGasReport(context.Background(), &feeHistoryClient{history: tt.history}, tt.blocks, nil); err == nil {
+			t.Errorf("%s: 期望返回错误", tt.name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package blockchain
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 日志字段名：所有日志对同一概念使用相同的键，便于按字段过滤 (如 jq 'select(.tx == "0x...")')
+const (
+	LogKeyBlock    = "block"    // 区块号
+	LogKeyTx       = "tx"       // 交易哈希
+	LogKeyEndpoint = "endpoint" // 节点地址 (已隐藏 API Key)
+	LogKeyAttempt  = "attempt"  // 第几次尝试 (从 1 开始)
+	LogKeyError    = "err"      // 错误信息
+)
+
+// LogOptions 日志输出选项
+type LogOptions struct {
+	Format string    // text (默认) 或 json
+	Level  string    // debug / info (默认) / warn / error
+	Writer io.Writer // 默认 stderr；日志始终与 stdout 上的数据输出分开
+}
+
+var currentLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	currentLogger.Store(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+}
+
+// NewLogger 按选项创建 slog.Logger
+func NewLogger(opts LogOptions) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(opts.Level))); err != nil && opts.Level != "" {
+		return nil, fmt.Errorf("不支持的日志级别 %q (可选: debug / info / warn / error)", opts.Level)
+	}
+	w := opts.Writer
+	if w == nil {
+		w = os.Stderr
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOpts)), nil
+	default:
+		return nil, fmt.Errorf("不支持的日志格式 %q (可选: text / json)", opts.Format)
+	}
+}
+
+// SetLogger 设置 blockchain 包使用的日志记录器
+func SetLogger(l *slog.Logger) {
+	if l != nil {
+		currentLogger.Store(l)
+	}
+}
+
+// Logger 返回 blockchain 包当前使用的日志记录器
+func Logger() *slog.Logger {
+	return currentLogger.Load()
+}
+
+func logBlock(n uint64) slog.Attr {
+	return slog.Uint64(LogKeyBlock, n)
+}
+
+func logTx(hash common.Hash) slog.Attr {
+	return slog.String(LogKeyTx, hash.Hex())
+}
+
+// logEndpoint 记录节点地址，路径与查询参数 (通常包含 API Key) 会被隐藏
+func logEndpoint(rawURL string) slog.Attr {
+	return slog.String(LogKeyEndpoint, redactURL(rawURL))
+}
+
+func logAttempt(n int) slog.Attr {
+	return slog.Int(LogKeyAttempt, n)
+}
+
+func logErr(err error) slog.Attr {
+	return slog.Any(LogKeyError, err)
+}
@@ -0,0 +1,196 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Multicall3Address 是 Multicall3 合约的地址，在主网、Sepolia 及绝大多数 EVM 网络上相同
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// DefaultMulticallChunkSize 单次 eth_call 中聚合的最大调用数
+const DefaultMulticallChunkSize = 100
+
+const multicall3ABI = `[
+	{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}
+]`
+
+var parsedMulticall3ABI = mustParseABI(multicall3ABI)
+
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// MulticallCall 一次待聚合的只读合约调用
+type MulticallCall struct {
+	Target common.Address
+	ABI    *abi.ABI
+	Method string
+	Args   []interface{}
+}
+
+// MulticallResult 单个调用的结果。Success 为 false 时 Err 为解码后的失败原因。
+type MulticallResult struct {
+	Success    bool
+	Values     []interface{}
+	ReturnData []byte
+	Err        error
+}
+
+// Multicaller 基于 Multicall3 的只读调用聚合器
+type Multicaller struct {
+	client    Client
+	Address   common.Address // Multicall3 合约地址
+	ChunkSize int            // 每次 eth_call 聚合的最大调用数
+}
+
+// NewMulticaller 创建使用默认 Multicall3 地址和分块大小的聚合器
+func NewMulticaller(client Client) *Multicaller {
+	return &Multicaller{
+		client:    client,
+		Address:   Multicall3Address,
+		ChunkSize: DefaultMulticallChunkSize,
+	}
+}
+
+// Aggregate 将多个只读调用合并为尽量少的 eth_call。
+// 所有分块都固定在同一区块执行 (blockNumber 为 nil 时使用调用时的最新区块)，保证结果一致；
+// 单个调用失败不会影响其他调用。返回结果顺序与 calls 一致，并返回实际使用的区块号。
+func (m *Multicaller) Aggregate(ctx context.Context, calls []MulticallCall, blockNumber *big.Int) ([]MulticallResult, *big.Int, error) {
+	if blockNumber == nil {
+		latest, err := m.client.BlockNumber(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取最新区块号失败: %v", err)
+		}
+		blockNumber = new(big.Int).SetUint64(latest)
+	}
+
+	code, err := m.client.CodeAt(ctx, m.Address, blockNumber)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 Multicall3 合约代码失败: %v", err)
+	}
+	if len(code) == 0 {
+		return nil, nil, fmt.Errorf("区块 %s 时 Multicall3 (%s) 尚未部署", blockNumber, m.Address.Hex())
+	}
+
+	encoded := make([]multicall3Call, len(calls))
+	for i, call := range calls {
+		data, err := call.ABI.Pack(call.Method, call.Args...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("编码第 %d 个调用 (%s) 失败: %v", i, call.Method, err)
+		}
+		encoded[i] = multicall3Call{Target: call.Target, AllowFailure: true, CallData: data}
+	}
+
+	chunkSize := m.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultMulticallChunkSize
+	}
+
+	raw := make([]multicall3Result, 0, len(calls))
+	for start := 0; start < len(encoded); start += chunkSize {
+		end := min(start+chunkSize, len(encoded))
+		results, err := m.aggregateChunk(ctx, encoded[start:end], blockNumber)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw = append(raw, results...)
+	}
+
+	results := make([]MulticallResult, len(calls))
+	for i, r := range raw {
+		results[i].ReturnData = r.ReturnData
+		if !r.Success {
+			results[i].Err = DecodeRevertData(r.ReturnData, calls[i].ABI)
+			continue
+		}
+		values, err := calls[i].ABI.Unpack(calls[i].Method, r.ReturnData)
+		if err != nil {
+			results[i].Err = fmt.Errorf("解码返回值失败: %v", err)
+			continue
+		}
+		results[i].Success = true
+		results[i].Values = values
+	}
+	return results, blockNumber, nil
+}
+
+// aggregateChunk 执行一次 aggregate3 调用；因分块过大失败时 (超出 Gas 上限或响应过大) 自动对半拆分重试，
+// 网络错误、限流与上下文取消等其他错误直接返回，不会放大为更多请求
+func (m *Multicaller) aggregateChunk(ctx context.Context, calls []multicall3Call, blockNumber *big.Int) ([]multicall3Result, error) {
+	data, err := parsedMulticall3ABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("编码 aggregate3 失败: %v", err)
+	}
+
+	output, err := m.client.CallContract(ctx, ethereum.CallMsg{To: &m.Address, Data: data}, blockNumber)
+	if err != nil {
+		if len(calls) > 1 && ctx.Err() == nil && isChunkTooLargeError(err) {
+			half := len(calls) / 2
+			left, err := m.aggregateChunk(ctx, calls[:half], blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			right, err := m.aggregateChunk(ctx, calls[half:], blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			return append(left, right...), nil
+		}
+		return nil, fmt.Errorf("Multicall3 调用失败: %w", DecodeContractError(err))
+	}
+
+	values, err := parsedMulticall3ABI.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, fmt.Errorf("解码 aggregate3 返回值失败: %v", err)
+	}
+	results := *abi.ConvertType(values[0], new([]multicall3Result)).(*[]multicall3Result)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("aggregate3 返回 %d 个结果，期望 %d 个", len(results), len(calls))
+	}
+	return results, nil
+}
+
+// chunkTooLargeMessages 节点因单次调用过大而拒绝时的错误信息 (geth、Erigon、Nethermind 及常见 RPC 服务商)
+var chunkTooLargeMessages = []string{
+	"out of gas",
+	"gas required exceeds",
+	"exceeds block gas limit",
+	"gas limit reached",
+	"request entity too large",
+	"response size exceeded",
+	"response is too big",
+	"response too large",
+	"exceeds the configured limit",
+}
+
+// isChunkTooLargeError 判断 aggregate3 失败是否因分块过大，只有这类错误拆分后才可能成功
+func isChunkTooLargeError(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range chunkTooLargeMessages {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// chunkLimitClient 模拟节点：分块超过 limit 个调用时返回 tooLarge，err 不为 nil 时所有调用都返回 err
+type chunkLimitClient struct {
+	Client
+	limit    int
+	tooLarge error
+	err      error
+	calls    int
+}
+
+func (c *chunkLimitClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	args, err := parsedMulticall3ABI.Methods["aggregate3"].Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	n := len(args[0].([]struct {
+		Target       common.Address `json:"target"`
+		AllowFailure bool           `json:"allowFailure"`
+		CallData     []byte         `json:"callData"`
+	}))
+	if n > c.limit {
+		return nil, c.tooLarge
+	}
+	results := make([]multicall3Result, n)
+	for i := range results {
+		results[i] = multicall3Result{Success: true, ReturnData: []byte{byte(i)}}
+	}
+	return parsedMulticall3ABI.Methods["aggregate3"].Outputs.Pack(results)
+}
+
+func TestAggregateChunkSplitsOnlyOnSizeErrors(t *testing.T) {
+	calls := make([]multicall3Call, 8)
+	for i := range calls {
+		calls[i] = multicall3Call{Target: common.BigToAddress(big.NewInt(int64(i + 1))), AllowFailure: true}
+	}
+
+	for _, tooLarge := range []error{
+		errors.New("out of gas"),
+		errors.New("gas required exceeds allowance (30000000)"),
+		rpc.HTTPError{StatusCode: 413, Status: "413 Request Entity Too Large"},
+	} {
+		client := &chunkLimitClient{limit: 2, tooLarge: tooLarge}
+		m := &Multicaller{client: client, Address: Multicall3Address}
+		results, err := m.aggregateChunk(context.Background(), calls, big.NewInt(1))
+		if err != nil {
+			t.Fatalf("%v: 拆分后应成功，得到 %v", tooLarge, err)
+		}
+		if len(results) != len(calls) {
+			t.Fatalf("%v: 返回 %d 个结果，期望 %d 个", tooLarge, len(results), len(calls))
+		}
+		// 8 -> 4+4 -> 2+2+2+2，共 7 次调用
+		if client.calls != 7 {
+			t.Errorf("%v: CallContract 调用 %d 次，期望 7 次", tooLarge, client.calls)
+		}
+	}
+
+	for _, other := range []error{
+		errors.New("connection refused"),
+		rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"},
+		fmt.Errorf("请求失败: %w", context.DeadlineExceeded),
+	} {
+		client := &chunkLimitClient{err: other}
+		m := &Multicaller{client: client, Address: Multicall3Address}
+		if _, err := m.aggregateChunk(context.Background(), calls, big.NewInt(1)); err == nil || !strings.Contains(err.Error(), "Multicall3 调用失败") {
+			t.Fatalf("%v: 期望直接返回错误，得到 %v", other, err)
+		}
+		if client.calls != 1 {
+			t.Errorf("%v: CallContract 调用 %d 次，期望 1 次 (不拆分)", other, client.calls)
+		}
+	}
+}
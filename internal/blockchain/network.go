@@ -0,0 +1,66 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrChainIDMismatch 节点返回的链 ID 与配置的期望值不一致
+var ErrChainIDMismatch = errors.New("链 ID 不匹配")
+
+// NetworkSettings 所选网络中影响签名校验、输出与等待上链行为的设置 (来自网络配置文件或环境变量)。
+// 设置随客户端传递 (见 NodeClient.Network 与 NetworkOf)，同一进程中连接多个网络时互不影响。
+type NetworkSettings struct {
+	ChainID       uint64 // 期望的链 ID，0 表示未配置；签名前与节点返回的链 ID 比对
+	ExplorerURL   string // 区块浏览器地址，为空时不输出链接
+	Confirmations uint64 // 等待交易上链时要求的确认数，0 或 1 表示回执出现即可
+}
+
+// NetworkOf 返回客户端附带的网络设置。
+// 客户端未附带设置时 (如模拟后端) 返回零值：不校验链 ID、回执出现即视为确认、不输出浏览器链接。
+func NetworkOf(client Client) NetworkSettings {
+	if c, ok := client.(interface{ NetworkSettings() NetworkSettings }); ok {
+		return c.NetworkSettings()
+	}
+	return NetworkSettings{}
+}
+
+// VerifyChainID 查询节点的链 ID (eth_chainId) 并与 expected 比对，expected 为 0 时只返回节点的链 ID
+func VerifyChainID(ctx context.Context, client Client, expected uint64) (*big.Int, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+	if err := checkChainID(chainID, expected); err != nil {
+		return nil, err
+	}
+	return chainID, nil
+}
+
+// RequiredConfirmations 返回等待交易时要求的确认数 (至少为 1)
+func (s NetworkSettings) RequiredConfirmations() uint64 {
+	return max(s.Confirmations, 1)
+}
+
+// ExplorerTxURL 返回交易在区块浏览器中的链接，未配置浏览器时返回空字符串
+func (s NetworkSettings) ExplorerTxURL(hash string) string {
+	if base := s.explorerBase(); base != "" {
+		return base + "/tx/" + hash
+	}
+	return ""
+}
+
+// ExplorerAddressURL 返回地址在区块浏览器中的链接，未配置浏览器时返回空字符串
+func (s NetworkSettings) ExplorerAddressURL(address string) string {
+	if base := s.explorerBase(); base != "" {
+		return base + "/address/" + address
+	}
+	return ""
+}
+
+func (s NetworkSettings) explorerBase() string {
+	return strings.TrimRight(strings.TrimSpace(s.ExplorerURL), "/")
+}
@@ -0,0 +1,45 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestNetworkOf(t *testing.T) {
+	node := &NodeClient{Network: NetworkSettings{ChainID: 11155111, ExplorerURL: " https://sepolia.etherscan.io/ ", Confirmations: 3}}
+	cached, err := NewCachingClient(context.Background(), node, CacheOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 缓存层保留底层客户端的网络设置
+	for _, client := range []Client{node, cached} {
+		s := NetworkOf(client)
+		if s.ChainID != 11155111 || s.RequiredConfirmations() != 3 {
+			t.Errorf("%T: 网络设置 = %+v", client, s)
+		}
+		if got, want := s.ExplorerTxURL("0xabc"), "https://sepolia.etherscan.io/tx/0xabc"; got != want {
+			t.Errorf("%T: ExplorerTxURL = %s, 期望 %s", client, got, want)
+		}
+	}
+
+	// 未附带设置的客户端使用零值
+	s := NetworkOf(&feeHistoryClient{})
+	if s.ChainID != 0 || s.RequiredConfirmations() != 1 || s.ExplorerAddressURL("0xabc") != "" {
+		t.Errorf("未附带设置时应为零值，得到 %+v", s)
+	}
+}
+
+func TestCheckChainID(t *testing.T) {
+	if err := checkChainID(big.NewInt(1), 0); err != nil {
+		t.Errorf("未配置期望链 ID 时应跳过校验，得到 %v", err)
+	}
+	if err := checkChainID(big.NewInt(11155111), 11155111); err != nil {
+		t.Errorf("链 ID 一致时不应返回错误，得到 %v", err)
+	}
+	if err := checkChainID(big.NewInt(1), 11155111); !errors.Is(err, ErrChainIDMismatch) {
+		t.Errorf("链 ID 不一致时应返回 ErrChainIDMismatch，得到 %v", err)
+	}
+}
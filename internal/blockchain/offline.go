@@ -0,0 +1,283 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// UnsignedTx 是冷钱包流程中待签名交易的文件格式 (JSON)。
+// 金额与费用字段均为十进制字符串 (单位: Wei)，避免 JSON 数字精度丢失。
+type UnsignedTx struct {
+	ChainID   string `json:"chainId"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Nonce     uint64 `json:"nonce"`
+	Value     string `json:"value"`
+	Gas       uint64 `json:"gas"`
+	GasTipCap string `json:"maxPriorityFeePerGas"`
+	GasFeeCap string `json:"maxFeePerGas"`
+	Data      string `json:"data,omitempty"`
+}
+
+// BuildUnsignedTx 在联网机器上构建一笔未签名的 ETH 转账交易，value 单位为 Wei。
+// 它从节点获取链 ID、nonce 和 EIP-1559 费用，但不需要私钥。
+// expectedChainID 不为 0 时，会校验节点返回的链 ID 与之一致。
+func BuildUnsignedTx(ctx context.Context, client Client, fromAddressHex string, toAddressHex string, value *big.Int, expectedChainID uint64) (*UnsignedTx, error) {
+	if !common.IsHexAddress(fromAddressHex) {
+		return nil, fmt.Errorf("无效的发送方地址: %s", fromAddressHex)
+	}
+	if !common.IsHexAddress(toAddressHex) {
+		return nil, fmt.Errorf("无效的接收方地址: %s", toAddressHex)
+	}
+	if value == nil || value.Sign() < 0 {
+		return nil, fmt.Errorf("无效的转账金额: %v", value)
+	}
+	fromAddress := common.HexToAddress(fromAddressHex)
+	toAddress := common.HexToAddress(toAddressHex)
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+	if err := checkChainID(chainID, expectedChainID); err != nil {
+		return nil, err
+	}
+
+	nonce, err := client.PendingNonceAt(ctx, fromAddress)
+	if err != nil {
+		return nil, fmt.Errorf("获取 nonce 失败: %v", err)
+	}
+
+	gasTipCap, gasFeeCap, err := suggestDynamicFees(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnsignedTx{
+		ChainID:   chainID.String(),
+		From:      fromAddress.Hex(),
+		To:        toAddress.Hex(),
+		Nonce:     nonce,
+		Value:     value.String(),
+		Gas:       21000, // ETH 转账的标准限制
+		GasTipCap: gasTipCap.String(),
+		GasFeeCap: gasFeeCap.String(),
+	}, nil
+}
+
+// Transaction 将文件格式转换为 go-ethereum 的 EIP-1559 交易对象
+func (u *UnsignedTx) Transaction() (*types.Transaction, error) {
+	chainID, err := parseWei("chainId", u.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseWei("value", u.Value)
+	if err != nil {
+		return nil, err
+	}
+	gasTipCap, err := parseWei("maxPriorityFeePerGas", u.GasTipCap)
+	if err != nil {
+		return nil, err
+	}
+	gasFeeCap, err := parseWei("maxFeePerGas", u.GasFeeCap)
+	if err != nil {
+		return nil, err
+	}
+	if gasFeeCap.Cmp(gasTipCap) < 0 {
+		return nil, fmt.Errorf("maxFeePerGas 不能小于 maxPriorityFeePerGas")
+	}
+	if !common.IsHexAddress(u.To) {
+		return nil, fmt.Errorf("无效的接收方地址: %s", u.To)
+	}
+	toAddress := common.HexToAddress(u.To)
+
+	var data []byte
+	if u.Data != "" {
+		data, err = hexutil.Decode(u.Data)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 data 字段: %v", err)
+		}
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     u.Nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       u.Gas,
+		To:        &toAddress,
+		Value:     value,
+		Data:      data,
+	}), nil
+}
+
+// WriteUnsignedTx 将未签名交易写入 JSON 文件
+func WriteUnsignedTx(path string, u *UnsignedTx) error {
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化未签名交易失败: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	return nil
+}
+
+// ReadUnsignedTx 从 JSON 文件读取未签名交易
+func ReadUnsignedTx(path string) (*UnsignedTx, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件 %s 失败: %v", path, err)
+	}
+	var u UnsignedTx
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, fmt.Errorf("解析未签名交易失败: %v", err)
+	}
+	return &u, nil
+}
+
+// SignUnsignedTx 在离线机器上签名交易，不发起任何 RPC 调用。
+// expectedChainID 必须与文件中的链 ID 一致，且私钥对应的地址必须与 from 一致。
+func SignUnsignedTx(u *UnsignedTx, privateKeyHex string, expectedChainID uint64) (*types.Transaction, error) {
+	if expectedChainID == 0 {
+		return nil, fmt.Errorf("离线签名需要通过 -chain-id 明确指定链 ID")
+	}
+
+	tx, err := u.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkChainID(tx.ChainId(), expectedChainID); err != nil {
+		return nil, err
+	}
+
+	privateKey, fromAddress, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	if u.From != "" && common.HexToAddress(u.From) != fromAddress {
+		return nil, fmt.Errorf("私钥地址 %s 与交易发送方 %s 不一致", fromAddress.Hex(), u.From)
+	}
+
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(tx.ChainId()), privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %v", err)
+	}
+	return signedTx, nil
+}
+
+// EncodeRawTx 将已签名交易编码为 RLP 十六进制字符串 (带 0x 前缀)
+func EncodeRawTx(tx *types.Transaction) (string, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("编码交易失败: %v", err)
+	}
+	return hexutil.Encode(raw), nil
+}
+
+// DecodeRawTx 从 RLP 十六进制字符串解码已签名交易
+func DecodeRawTx(rawHex string) (*types.Transaction, error) {
+	rawHex = strings.TrimSpace(rawHex)
+	if !strings.HasPrefix(rawHex, "0x") {
+		rawHex = "0x" + rawHex
+	}
+	raw, err := hexutil.Decode(rawHex)
+	if err != nil {
+		return nil, fmt.Errorf("无效的交易十六进制: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("解码交易失败: %v", err)
+	}
+	return tx, nil
+}
+
+// BroadcastRawTx 广播已签名的原始交易。
+// 广播前会校验交易的链 ID 与节点一致，以及 expectedChainID (不为 0 时)。
+func BroadcastRawTx(ctx context.Context, client Client, rawHex string, expectedChainID uint64) (*types.Transaction, error) {
+	tx, err := DecodeRawTx(rawHex)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+	if tx.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("交易链 ID (%s) 与节点链 ID (%s) 不一致", tx.ChainId(), chainID)
+	}
+	if err := checkChainID(chainID, expectedChainID); err != nil {
+		return nil, err
+	}
+
+	PrintTxSummary(tx)
+
+	if err := sendTransactionWithRetry(ctx, client, tx); err != nil {
+		return nil, fmt.Errorf("发送交易失败: %v", err)
+	}
+	return tx, nil
+}
+
+// PrintTxSummary 打印交易的可读摘要，供构建、签名和广播各步骤核对
+func PrintTxSummary(tx *types.Transaction) {
+	fmt.Fprintln(TextOut(), "------------------------------------------------")
+	fmt.Fprintf(TextOut(), "链 ID:      %s (%s)\n", tx.ChainId(), ChainName(tx.ChainId()))
+	if from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+		fmt.Fprintf(TextOut(), "发送方:     %s\n", from.Hex())
+	} else {
+		fmt.Fprintln(TextOut(), "发送方:     (未签名)")
+	}
+	if tx.To() != nil {
+		fmt.Fprintf(TextOut(), "接收方:     %s\n", tx.To().Hex())
+	} else {
+		fmt.Fprintln(TextOut(), "接收方:     (合约创建)")
+	}
+	fmt.Fprintf(TextOut(), "金额:       %s ETH\n", FormatEther(tx.Value()))
+	fmt.Fprintf(TextOut(), "Nonce:      %d\n", tx.Nonce())
+	fmt.Fprintf(TextOut(), "Gas 限制:   %d\n", tx.Gas())
+	fmt.Fprintf(TextOut(), "优先费上限: %s Gwei\n", FormatGwei(tx.GasTipCap()))
+	fmt.Fprintf(TextOut(), "费用上限:   %s Gwei\n", FormatGwei(tx.GasFeeCap()))
+	fmt.Fprintf(TextOut(), "最大花费:   %s ETH\n", FormatEther(tx.Cost()))
+	if len(tx.Data()) > 0 {
+		fmt.Fprintf(TextOut(), "Data 长度:  %d 字节\n", len(tx.Data()))
+	}
+	if isSigned(tx) {
+		fmt.Fprintf(TextOut(), "交易哈希:   %s\n", tx.Hash().Hex())
+	}
+	fmt.Fprintln(TextOut(), "------------------------------------------------")
+}
+
+// checkChainID 校验实际链 ID 与期望值一致，期望值为 0 (未配置) 时跳过
+func checkChainID(actual *big.Int, expected uint64) error {
+	if expected == 0 {
+		return nil
+	}
+	if actual == nil || !actual.IsUint64() || actual.Uint64() != expected {
+		return fmt.Errorf("%w: 期望 %d (%s)，节点返回 %s (%s)", ErrChainIDMismatch,
+			expected, ChainName(new(big.Int).SetUint64(expected)), actual, ChainName(actual))
+	}
+	return nil
+}
+
+// parseWei 解析十进制字符串形式的大整数字段
+func parseWei(field string, s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("无效的 %s 字段: %q", field, s)
+	}
+	return v, nil
+}
+
+func isSigned(tx *types.Transaction) bool {
+	v, r, s := tx.RawSignatureValues()
+	return v != nil && r != nil && s != nil && (r.Sign() != 0 || s.Sign() != 0)
+}
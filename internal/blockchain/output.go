@@ -0,0 +1,497 @@
+package blockchain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// OutputFormat 命令行结果的输出格式
+type OutputFormat string
+
+const (
+	OutputText   OutputFormat = "text"   // 中文可读文本 (默认)
+	OutputJSON   OutputFormat = "json"   // 全部记录在退出时合并为一个缩进 JSON 数组
+	OutputNDJSON OutputFormat = "ndjson" // 每行一个 JSON 对象，适合订阅与扫描等流式输出
+	OutputCSV    OutputFormat = "csv"    // 带表头的 CSV
+)
+
+// Record 可按结构化格式输出的记录。JSON 字段由结构体标签决定，CSV 列由 CSVHeader 决定。
+// 字段名和列顺序属于对外接口，只能追加不能修改。
+type Record interface {
+	CSVHeader() []string
+	CSVRow() []string
+}
+
+var (
+	outputMu     sync.Mutex
+	outputFormat           = OutputText
+	dataOut      io.Writer = os.Stdout // 结构化记录
+	textOut      io.Writer = os.Stdout // 可读文本与提示信息
+	csvWriter    *csv.Writer
+	csvHeader    []string
+	jsonRecords  []json.RawMessage // json 格式下缓冲的记录，由 FlushOutput 写出
+)
+
+// SetOutputFormat 设置全局输出格式。
+// 非 text 格式下 stdout 只输出结构化记录，可读文本与提示信息 (TextOut) 改为写入 stderr，因此不会混入数据。
+func SetOutputFormat(s string) error {
+	format := OutputFormat(strings.ToLower(strings.TrimSpace(s)))
+	switch format {
+	case "":
+		format = OutputText
+	case OutputText, OutputJSON, OutputNDJSON, OutputCSV:
+	default:
+		return fmt.Errorf("不支持的输出格式 %q (可选: text / json / ndjson / csv)", s)
+	}
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	outputFormat = format
+	textOut = os.Stdout
+	if format != OutputText {
+		textOut = os.Stderr
+	}
+	return nil
+}
+
+// TextOut 返回可读文本的输出位置：text 格式下为 stdout，其他格式下为 stderr
+func TextOut() io.Writer {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	return textOut
+}
+
+// IsTextOutput 当前是否为默认的文本输出
+func IsTextOutput() bool {
+	return currentOutputFormat() == OutputText
+}
+
+// SupportsStreaming 当前格式能否逐条写出记录。json 格式在退出时才输出数组，不适合订阅等持续运行的模式
+func SupportsStreaming() bool {
+	return currentOutputFormat() != OutputJSON
+}
+
+func currentOutputFormat() OutputFormat {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	return outputFormat
+}
+
+// Emit 按当前输出格式写出一条记录；text 格式下不输出 (由调用方打印可读文本)。
+// json 格式下记录先缓冲，由 FlushOutput 合并为一个数组；CSV 格式下同一次运行只能输出一种记录。
+func Emit(r Record) error {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	switch outputFormat {
+	case OutputJSON:
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		jsonRecords = append(jsonRecords, data)
+	case OutputNDJSON:
+		return json.NewEncoder(dataOut).Encode(r)
+	case OutputCSV:
+		if csvWriter == nil {
+			csvWriter = csv.NewWriter(dataOut)
+		}
+		// 表头只在第一条记录时输出；列不同的记录无法写入同一个 CSV
+		header := r.CSVHeader()
+		if csvHeader == nil {
+			if err := csvWriter.Write(header); err != nil {
+				return err
+			}
+			csvHeader = header
+		} else if !slices.Equal(header, csvHeader) {
+			return fmt.Errorf("CSV 输出不能混合不同类型的记录 (列 %s 与已输出的 %s 不一致)，请使用 -output ndjson",
+				strings.Join(header, ","), strings.Join(csvHeader, ","))
+		}
+		if err := csvWriter.Write(r.CSVRow()); err != nil {
+			return err
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+	return nil
+}
+
+// FlushOutput 写出缓冲的记录：json 格式下输出一个包含全部记录的 JSON 数组 (没有记录时为 [])。
+// 程序退出前调用一次，其他格式下不做任何事
+func FlushOutput() error {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	if outputFormat != OutputJSON {
+		return nil
+	}
+	records := jsonRecords
+	jsonRecords = nil
+	if records == nil {
+		records = []json.RawMessage{}
+	}
+	enc := json.NewEncoder(dataOut)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// emitOrWarn 输出记录，失败时打印到 stderr (用于没有错误返回值的打印函数)
+func emitOrWarn(r Record) {
+	if err := Emit(r); err != nil {
+		Logger().Error("输出记录失败", logErr(err))
+	}
+}
+
+// HeaderRecord 区块头的结构化输出
+type HeaderRecord struct {
+	Number        uint64 `json:"number"`
+	Hash          string `json:"hash"`
+	ParentHash    string `json:"parentHash"`
+	Timestamp     uint64 `json:"timestamp"`
+	Nonce         uint64 `json:"nonce"`
+	Miner         string `json:"miner"`
+	GasUsed       uint64 `json:"gasUsed"`
+	GasLimit      uint64 `json:"gasLimit"`
+	BaseFeePerGas string `json:"baseFeePerGas,omitempty"` // wei
+}
+
+// NewHeaderRecord 由区块头构造输出记录
+func NewHeaderRecord(h *types.Header) *HeaderRecord {
+	return &HeaderRecord{
+		Number:        h.Number.Uint64(),
+		Hash:          h.Hash().Hex(),
+		ParentHash:    h.ParentHash.Hex(),
+		Timestamp:     h.Time,
+		Nonce:         h.Nonce.Uint64(),
+		Miner:         h.Coinbase.Hex(),
+		GasUsed:       h.GasUsed,
+		GasLimit:      h.GasLimit,
+		BaseFeePerGas: bigString(h.BaseFee),
+	}
+}
+
+func (r *HeaderRecord) CSVHeader() []string {
+	return []string{"number", "hash", "parent_hash", "timestamp", "nonce", "miner", "gas_used", "gas_limit", "base_fee_per_gas"}
+}
+
+func (r *HeaderRecord) CSVRow() []string {
+	return []string{u64(r.Number), r.Hash, r.ParentHash, u64(r.Timestamp), u64(r.Nonce), r.Miner, u64(r.GasUsed), u64(r.GasLimit), r.BaseFeePerGas}
+}
+
+// BlockRecord 区块的结构化输出。Transactions 仅在详情模式下填充。
+// CSV 格式下只输出区块汇总行；详情模式的 CSV 改为逐笔输出 TxRecord。
+type BlockRecord struct {
+	Number          uint64      `json:"number"`
+	Hash            string      `json:"hash"`
+	ParentHash      string      `json:"parentHash"`
+	Timestamp       uint64      `json:"timestamp"`
+	Miner           string      `json:"miner"`
+	GasUsed         uint64      `json:"gasUsed"`
+	GasLimit        uint64      `json:"gasLimit"`
+	BaseFeePerGas   string      `json:"baseFeePerGas,omitempty"` // wei
+	BlobGasUsed     *uint64     `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas   *uint64     `json:"excessBlobGas,omitempty"`
+	WithdrawalCount *int        `json:"withdrawalCount,omitempty"`
+	WithdrawalTotal string      `json:"withdrawalTotal,omitempty"` // wei
+	Size            uint64      `json:"size"`
+	TxCount         int         `json:"txCount"`
+	Transactions    []*TxRecord `json:"transactions,omitempty"`
+}
+
+// NewBlockRecord 由区块构造输出记录 (不含交易明细)
+func NewBlockRecord(b *types.Block) *BlockRecord {
+	r := &BlockRecord{
+		Number:        b.NumberU64(),
+		Hash:          b.Hash().Hex(),
+		ParentHash:    b.ParentHash().Hex(),
+		Timestamp:     b.Time(),
+		Miner:         b.Coinbase().Hex(),
+		GasUsed:       b.GasUsed(),
+		GasLimit:      b.GasLimit(),
+		BaseFeePerGas: bigString(b.BaseFee()),
+		BlobGasUsed:   b.BlobGasUsed(),
+		ExcessBlobGas: b.ExcessBlobGas(),
+		Size:          b.Size(),
+		TxCount:       len(b.Transactions()),
+	}
+	if withdrawals := b.Withdrawals(); withdrawals != nil {
+		count := len(withdrawals)
+		r.WithdrawalCount = &count
+		r.WithdrawalTotal = withdrawalTotal(withdrawals).String()
+	}
+	return r
+}
+
+func (r *BlockRecord) CSVHeader() []string {
+	return []string{"number", "hash", "parent_hash", "timestamp", "miner", "gas_used", "gas_limit", "base_fee_per_gas",
+		"blob_gas_used", "excess_blob_gas", "withdrawal_count", "withdrawal_total", "size", "tx_count"}
+}
+
+func (r *BlockRecord) CSVRow() []string {
+	withdrawalCount := ""
+	if r.WithdrawalCount != nil {
+		withdrawalCount = strconv.Itoa(*r.WithdrawalCount)
+	}
+	return []string{u64(r.Number), r.Hash, r.ParentHash, u64(r.Timestamp), r.Miner, u64(r.GasUsed), u64(r.GasLimit), r.BaseFeePerGas,
+		optU64(r.BlobGasUsed), optU64(r.ExcessBlobGas), withdrawalCount, r.WithdrawalTotal, u64(r.Size), strconv.Itoa(r.TxCount)}
+}
+
+// TxRecord 交易的结构化输出，金额与费用均为 wei 的十进制字符串。
+// 区块内的交易带有 BlockNumber / Index；带回执时填充 Receipt。
+type TxRecord struct {
+	Hash                 string         `json:"hash"`
+	BlockNumber          *uint64        `json:"blockNumber,omitempty"`
+	Index                *int           `json:"index,omitempty"`
+	Type                 uint8          `json:"type"`
+	ChainID              string         `json:"chainId,omitempty"`
+	From                 string         `json:"from,omitempty"`
+	To                   string         `json:"to,omitempty"` // 空表示合约创建
+	Value                string         `json:"value"`
+	Nonce                uint64         `json:"nonce"`
+	Gas                  uint64         `json:"gas"`
+	GasPrice             string         `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string         `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string         `json:"maxPriorityFeePerGas,omitempty"`
+	Selector             string         `json:"selector,omitempty"`
+	BlobCount            int            `json:"blobCount,omitempty"`
+	Raw                  string         `json:"raw,omitempty"` // 已签名交易的 RLP 十六进制 (sign-tx 模式)
+	Receipt              *ReceiptRecord `json:"receipt,omitempty"`
+}
+
+// NewTxRecord 由交易构造输出记录；signer 为 nil 时按交易自身的链 ID 恢复发送方
+func NewTxRecord(tx *types.Transaction, signer types.Signer) *TxRecord {
+	r := &TxRecord{
+		Hash:      tx.Hash().Hex(),
+		Type:      tx.Type(),
+		Value:     tx.Value().String(),
+		Nonce:     tx.Nonce(),
+		Gas:       tx.Gas(),
+		BlobCount: len(tx.BlobHashes()),
+	}
+	if chainID := tx.ChainId(); chainID != nil && chainID.Sign() > 0 {
+		r.ChainID = chainID.String()
+	}
+	if signer == nil {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	if isSigned(tx) {
+		if from, err := types.Sender(signer, tx); err == nil {
+			r.From = from.Hex()
+		}
+	}
+	if tx.To() != nil {
+		r.To = tx.To().Hex()
+	}
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		r.GasPrice = tx.GasPrice().String()
+	} else {
+		r.MaxFeePerGas = tx.GasFeeCap().String()
+		r.MaxPriorityFeePerGas = tx.GasTipCap().String()
+	}
+	if len(tx.Data()) > 0 {
+		r.Selector = inputSelector(tx.Data())
+	}
+	return r
+}
+
+var receiptCSVHeader = []string{"status", "gas_used", "effective_gas_price", "fee", "log_count", "contract_address"}
+
+func (r *TxRecord) CSVHeader() []string {
+	header := []string{"hash", "block_number", "index", "type", "chain_id", "from", "to", "value", "nonce", "gas",
+		"gas_price", "max_fee_per_gas", "max_priority_fee_per_gas", "selector", "blob_count"}
+	header = append(header, receiptCSVHeader...)
+	return append(header, "raw")
+}
+
+func (r *TxRecord) CSVRow() []string {
+	index := ""
+	if r.Index != nil {
+		index = strconv.Itoa(*r.Index)
+	}
+	row := []string{r.Hash, optU64(r.BlockNumber), index, strconv.Itoa(int(r.Type)), r.ChainID, r.From, r.To, r.Value, u64(r.Nonce), u64(r.Gas),
+		r.GasPrice, r.MaxFeePerGas, r.MaxPriorityFeePerGas, r.Selector, strconv.Itoa(r.BlobCount)}
+	if r.Receipt == nil {
+		row = append(row, make([]string, len(receiptCSVHeader))...)
+	} else {
+		row = append(row, r.Receipt.CSVRow()[4:]...)
+	}
+	return append(row, r.Raw)
+}
+
+// ReceiptRecord 交易回执的结构化输出
+type ReceiptRecord struct {
+	TxHash            string       `json:"txHash"`
+	BlockNumber       uint64       `json:"blockNumber"`
+	BlockHash         string       `json:"blockHash"`
+	TxIndex           uint         `json:"txIndex"`
+	Status            uint64       `json:"status"` // 1 成功，0 失败
+	GasUsed           uint64       `json:"gasUsed"`
+	EffectiveGasPrice string       `json:"effectiveGasPrice,omitempty"` // wei
+	Fee               string       `json:"fee,omitempty"`               // gasUsed * effectiveGasPrice，wei
+	LogCount          int          `json:"logCount"`
+	ContractAddress   string       `json:"contractAddress,omitempty"`
+	Logs              []*LogRecord `json:"logs,omitempty"` // 仅 receipt 模式填充；CSV 中不输出
+}
+
+// NewReceiptRecord 由交易回执构造输出记录
+func NewReceiptRecord(r *types.Receipt) *ReceiptRecord {
+	rec := &ReceiptRecord{
+		TxHash:    r.TxHash.Hex(),
+		BlockHash: r.BlockHash.Hex(),
+		TxIndex:   r.TransactionIndex,
+		Status:    r.Status,
+		GasUsed:   r.GasUsed,
+		LogCount:  len(r.Logs),
+	}
+	if r.BlockNumber != nil {
+		rec.BlockNumber = r.BlockNumber.Uint64()
+	}
+	if r.EffectiveGasPrice != nil {
+		rec.EffectiveGasPrice = r.EffectiveGasPrice.String()
+		rec.Fee = new(big.Int).Mul(r.EffectiveGasPrice, new(big.Int).SetUint64(r.GasUsed)).String()
+	}
+	if r.ContractAddress != (common.Address{}) {
+		rec.ContractAddress = r.ContractAddress.Hex()
+	}
+	return rec
+}
+
+func (r *ReceiptRecord) CSVHeader() []string {
+	return append([]string{"tx_hash", "block_number", "block_hash", "tx_index"}, receiptCSVHeader...)
+}
+
+func (r *ReceiptRecord) CSVRow() []string {
+	return []string{r.TxHash, u64(r.BlockNumber), r.BlockHash, strconv.FormatUint(uint64(r.TxIndex), 10),
+		u64(r.Status), u64(r.GasUsed), r.EffectiveGasPrice, r.Fee, strconv.Itoa(r.LogCount), r.ContractAddress}
+}
+
+// LogRecord 合约日志的结构化输出
+type LogRecord struct {
+	BlockNumber uint64   `json:"blockNumber"`
+	BlockHash   string   `json:"blockHash"`
+	TxHash      string   `json:"txHash"`
+	TxIndex     uint     `json:"txIndex"`
+	LogIndex    uint     `json:"logIndex"`
+	Address     string   `json:"address"`
+	Topics      []string `json:"topics"`
+	Data        string   `json:"data"`
+	Removed     bool     `json:"removed"`
+}
+
+// NewLogRecord 由日志构造输出记录
+func NewLogRecord(l types.Log) *LogRecord {
+	topics := make([]string, len(l.Topics))
+	for i, t := range l.Topics {
+		topics[i] = t.Hex()
+	}
+	return &LogRecord{
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash.Hex(),
+		TxHash:      l.TxHash.Hex(),
+		TxIndex:     l.TxIndex,
+		LogIndex:    l.Index,
+		Address:     l.Address.Hex(),
+		Topics:      topics,
+		Data:        hexutil.Encode(l.Data),
+		Removed:     l.Removed,
+	}
+}
+
+func (r *LogRecord) CSVHeader() []string {
+	return []string{"block_number", "block_hash", "tx_hash", "tx_index", "log_index", "address", "topics", "data", "removed"}
+}
+
+func (r *LogRecord) CSVRow() []string {
+	return []string{u64(r.BlockNumber), r.BlockHash, r.TxHash, strconv.FormatUint(uint64(r.TxIndex), 10), strconv.FormatUint(uint64(r.LogIndex), 10),
+		r.Address, strings.Join(r.Topics, ";"), r.Data, strconv.FormatBool(r.Removed)}
+}
+
+// PrintTxSent 报告已发送的交易：text 格式打印交易哈希 (客户端配置了区块浏览器时附带链接)，其他格式输出 TxRecord
+func PrintTxSent(client Client, label string, tx *types.Transaction) {
+	if IsTextOutput() {
+		fmt.Fprintf(TextOut(), "%s。交易哈希: %s\n", label, tx.Hash().Hex())
+		if link := NetworkOf(client).ExplorerTxURL(tx.Hash().Hex()); link != "" {
+			fmt.Fprintf(TextOut(), "浏览器链接: %s\n", link)
+		}
+		return
+	}
+	emitOrWarn(NewTxRecord(tx, nil))
+}
+
+// BalanceRecord 余额查询的结构化输出；Token 为空表示 ETH
+type BalanceRecord struct {
+	Account   string `json:"account"`
+	Block     string `json:"block"` // 区块号或标签
+	Token     string `json:"token,omitempty"`
+	Symbol    string `json:"symbol"`
+	Decimals  uint8  `json:"decimals"`
+	Balance   string `json:"balance"`   // 最小单位 (ETH 为 wei)
+	Formatted string `json:"formatted"` // 按 decimals 换算后的数量
+}
+
+func (r *BalanceRecord) CSVHeader() []string {
+	return []string{"account", "block", "token", "symbol", "decimals", "balance", "formatted"}
+}
+
+func (r *BalanceRecord) CSVRow() []string {
+	return []string{r.Account, r.Block, r.Token, r.Symbol, strconv.Itoa(int(r.Decimals)), r.Balance, r.Formatted}
+}
+
+// PrintBalance 打印账户余额；token 为 nil 时为 ETH 余额。非 text 格式时输出 BalanceRecord
+func PrintBalance(account common.Address, block BlockRef, balance *big.Int, token *ERC20Info) error {
+	r := &BalanceRecord{Account: account.Hex(), Block: block.String(), Symbol: "ETH", Decimals: 18, Balance: balance.String()}
+	if token != nil {
+		r.Token = token.Address.Hex()
+		r.Symbol = token.Symbol
+		r.Decimals = token.Decimals
+	}
+	r.Formatted = FormatUnits(balance, r.Decimals)
+	if !IsTextOutput() {
+		return Emit(r)
+	}
+	if token != nil {
+		fmt.Fprintf(TextOut(), "区块 %s 时 %s 的 %s 余额: %s\n", block, r.Account, r.Symbol, r.Formatted)
+	} else {
+		fmt.Fprintf(TextOut(), "区块 %s 时 %s 的余额: %s ETH\n", block, r.Account, FormatEther(balance))
+	}
+	return nil
+}
+
+func withdrawalTotal(withdrawals types.Withdrawals) *big.Int {
+	total := new(big.Int)
+	for _, w := range withdrawals {
+		total.Add(total, new(big.Int).SetUint64(w.Amount)) // 单位: Gwei
+	}
+	return total.Mul(total, big.NewInt(params.GWei))
+}
+
+func bigString(v *big.Int) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
+
+func u64(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+func optU64(v *uint64) string {
+	if v == nil {
+		return ""
+	}
+	return u64(*v)
+}
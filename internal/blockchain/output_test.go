@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// captureOutput 将结构化输出切换为指定格式并写入缓冲区，测试结束后恢复
+func captureOutput(t *testing.T, format OutputFormat) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	outputMu.Lock()
+	prevFormat, prevOut := outputFormat, dataOut
+	outputFormat, dataOut = format, &buf
+	csvWriter, csvHeader, jsonRecords = nil, nil, nil
+	outputMu.Unlock()
+	t.Cleanup(func() {
+		outputMu.Lock()
+		outputFormat, dataOut = prevFormat, prevOut
+		csvWriter, csvHeader, jsonRecords = nil, nil, nil
+		outputMu.Unlock()
+	})
+	return &buf
+}
+
+func TestJSONOutputIsSingleArray(t *testing.T) {
+	buf := captureOutput(t, OutputJSON)
+	for _, contract := range []string{"0x01", "0x02"} {
+		if err := Emit(&CounterRecord{Contract: contract, Block: "latest", Count: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("json 格式应在 FlushOutput 时才输出，得到 %q", buf.String())
+	}
+	if err := FlushOutput(); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []CounterRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("输出不是一个合法的 JSON 数组: %v\n%s", err, buf.String())
+	}
+	if len(records) != 2 || records[1].Contract != "0x02" {
+		t.Errorf("记录 = %+v", records)
+	}
+}
+
+func TestJSONOutputWithoutRecords(t *testing.T) {
+	buf := captureOutput(t, OutputJSON)
+	if err := FlushOutput(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("没有记录时应输出 []，得到 %q", got)
+	}
+}
+
+func TestCSVOutputRejectsMixedRecords(t *testing.T) {
+	buf := captureOutput(t, OutputCSV)
+	for i := 0; i < 2; i++ {
+		if err := Emit(&CounterRecord{Contract: "0x01", Block: "latest", Count: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Emit(&BalanceRecord{Account: "0x01", Block: "latest", Symbol: "ETH", Balance: "0"}); err == nil {
+		t.Error("CSV 中混合不同类型的记录应返回错误")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Errorf("期望 1 行表头与 2 行数据，得到:\n%s", buf.String())
+	}
+}
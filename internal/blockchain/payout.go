@@ -0,0 +1,606 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 批量付款中每一行的状态
+const (
+	PayoutPending   = "pending"   // 尚未发送
+	PayoutSent      = "sent"      // 已广播，等待回执
+	PayoutConfirmed = "confirmed" // 已上链且执行成功
+	PayoutReverted  = "reverted"  // 已上链但执行失败，不会自动重发
+	PayoutFailed    = "failed"    // 节点明确拒绝或未能构建交易，重新运行时会重试
+	PayoutUnknown   = "unknown"   // 已签名，发送结果未知 (可能已被节点接收)，重新运行时先核对回执与 nonce
+)
+
+var payoutResultHeader = []string{"line", "address", "amount", "token", "status", "nonce", "tx_hash", "block", "gas_used", "error"}
+
+// PayoutRow 批量付款文件中的一行及其执行结果
+type PayoutRow struct {
+	Line    int    `json:"line"`    // 输入文件中的数据行序号 (从 1 开始，不含表头)
+	Address string `json:"address"` // 收款地址
+	Amount  string `json:"amount"`  // 金额 (ETH 或代币的十进制单位)
+	Token   string `json:"token"`   // 代币合约地址，空表示 ETH
+	Status  string `json:"status"`
+	Nonce   string `json:"nonce"`
+	TxHash  string `json:"txHash"`
+	Block   string `json:"block"`
+	GasUsed string `json:"gasUsed"`
+	Error   string `json:"error"`
+
+	to    common.Address
+	value *big.Int
+	token *ERC20Info
+}
+
+func (r *PayoutRow) isETH() bool {
+	return r.token == nil
+}
+
+func (r *PayoutRow) assetName() string {
+	if r.isETH() {
+		return "ETH"
+	}
+	if r.token.Symbol != "" {
+		return r.token.Symbol + " (" + r.token.Address.Hex() + ")"
+	}
+	return r.token.Address.Hex()
+}
+
+// PayoutOptions 批量付款的运行选项
+type PayoutOptions struct {
+	InputPath  string // 输入 CSV: address,amount[,token]
+	ResultPath string // 结果 CSV，存在时用于断点续发
+	DryRun     bool   // 只打印摘要，不发送
+	AssumeYes  bool   // 跳过交互确认
+}
+
+// RunPayout 执行批量付款。
+// 所有地址先经过校验和检查，打印汇总后才会发送；nonce 在本地顺序递增。
+// 每次状态变化都会写入结果文件，重新运行时已确认的行会被跳过，
+// 已发送或结果未知的行会先核对回执与 nonce，从而可以安全地补完部分完成的批次。
+// 交易哈希与 nonce 在广播前写入结果文件，发送超时或断线时不会因重新签名而重复付款。
+func RunPayout(ctx context.Context, client Client, privateKeyHex string, opts PayoutOptions) error {
+	if opts.ResultPath == "" {
+		opts.ResultPath = strings.TrimSuffix(opts.InputPath, ".csv") + ".result.csv"
+	}
+
+	privateKey, fromAddress, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return err
+	}
+
+	rows, err := readPayoutInput(opts.InputPath)
+	if err != nil {
+		return err
+	}
+	if err := mergePayoutResults(rows, opts.ResultPath); err != nil {
+		return err
+	}
+	if err := resolvePayoutRows(ctx, client, rows); err != nil {
+		return err
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+	if err := checkChainID(chainID, NetworkOf(client).ChainID); err != nil {
+		return fmt.Errorf("拒绝签名: %w", err)
+	}
+
+	if err := printPayoutSummary(ctx, client, fromAddress, chainID, rows); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		fmt.Fprintln(TextOut(), "Dry-run 模式: 未发送任何交易")
+		return nil
+	}
+	if IsMainnet(chainID) {
+		if !ConfirmMainnetWrite(chainID, "批量付款", opts.AssumeYes) {
+			return fmt.Errorf("用户取消了批量付款")
+		}
+	} else if !opts.AssumeYes && !Confirm("即将发送以上付款。", "yes") {
+		return fmt.Errorf("用户取消了批量付款")
+	}
+
+	// 1. 核对上次运行中已发送但未确认、或发送结果未知的交易
+	if err := reconcileSentPayouts(ctx, client, fromAddress, rows); err != nil {
+		return err
+	}
+	if err := writePayoutResults(opts.ResultPath, rows); err != nil {
+		return err
+	}
+
+	// 2. 顺序发送，nonce 在本地管理
+	nonce, err := client.PendingNonceAt(ctx, fromAddress)
+	if err != nil {
+		return fmt.Errorf("获取 nonce 失败: %v", err)
+	}
+	gasTipCap, gasFeeCap, err := suggestDynamicFees(ctx, client)
+	if err != nil {
+		return err
+	}
+	signer := types.NewLondonSigner(chainID)
+
+	for _, row := range rows {
+		if row.Status != PayoutPending && row.Status != PayoutFailed {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		tx, err := buildPayoutTx(ctx, client, fromAddress, chainID, nonce, gasTipCap, gasFeeCap, row)
+		if err == nil {
+			tx, err = types.SignTx(tx, signer, privateKey)
+		}
+		if err != nil {
+			Logger().Error("付款交易构建失败", "line", row.Line, logErr(err))
+			row.Status = PayoutFailed
+			row.Error = err.Error()
+			if err := writePayoutResults(opts.ResultPath, rows); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 广播前先记录哈希与 nonce：发送结果未知时，重新运行可据此核对，而不是用新的 nonce 重新签名
+		row.Status = PayoutUnknown
+		row.Nonce = strconv.FormatUint(nonce, 10)
+		row.TxHash = tx.Hash().Hex()
+		row.Error = ""
+		if err := writePayoutResults(opts.ResultPath, rows); err != nil {
+			return err
+		}
+
+		stop := false
+		err = sendTransactionWithRetry(ctx, client, tx)
+		switch {
+		case err == nil:
+			Logger().Info("付款已发送", "line", row.Line, "amount", row.Amount, "asset", row.assetName(), "to", row.to.Hex(), "nonce", nonce, logTx(tx.Hash()))
+			row.Status = PayoutSent
+			nonce++
+		case sendOutcomeUnknown(err):
+			// 交易可能已被接收，继续使用下一个 nonce 可能造成 nonce 空洞，因此停止发送，由重新运行核对
+			Logger().Error("付款发送结果未知，停止发送后续付款", "line", row.Line, "nonce", nonce, logTx(tx.Hash()), logErr(err))
+			row.Error = err.Error()
+			stop = true
+		default:
+			Logger().Error("付款发送失败", "line", row.Line, logTx(tx.Hash()), logErr(err))
+			row.Status = PayoutFailed
+			row.Nonce, row.TxHash = "", ""
+			row.Error = err.Error()
+		}
+		if err := writePayoutResults(opts.ResultPath, rows); err != nil {
+			return err
+		}
+		if stop {
+			break
+		}
+	}
+
+	// 3. 等待所有已发送交易的回执
+	for _, row := range rows {
+		if row.Status != PayoutSent {
+			continue
+		}
+		receipt, err := waitForReceipt(ctx, client, common.HexToHash(row.TxHash))
+		if err != nil {
+			return fmt.Errorf("等待第 %d 行交易回执失败 (可重新运行以继续): %v", row.Line, err)
+		}
+		applyPayoutReceipt(row, receipt)
+		if err := writePayoutResults(opts.ResultPath, rows); err != nil {
+			return err
+		}
+	}
+
+	counts := map[string]int{}
+	for _, row := range rows {
+		counts[row.Status]++
+		if !IsTextOutput() {
+			emitOrWarn(row)
+		}
+	}
+	fmt.Fprintf(TextOut(), "批量付款完成: 成功 %d, 回滚 %d, 失败 %d, 结果未知 %d。结果文件: %s\n",
+		counts[PayoutConfirmed], counts[PayoutReverted], counts[PayoutFailed], counts[PayoutUnknown], opts.ResultPath)
+	if counts[PayoutUnknown] > 0 {
+		return fmt.Errorf("有 %d 行付款发送结果未知，请重新运行同一命令以核对 (不会重复付款)", counts[PayoutUnknown])
+	}
+	if counts[PayoutFailed] > 0 || counts[PayoutReverted] > 0 {
+		return fmt.Errorf("有 %d 行付款未成功，请检查结果文件", counts[PayoutFailed]+counts[PayoutReverted])
+	}
+	return nil
+}
+
+// readPayoutInput 读取输入 CSV，首行为 address 开头时视为表头
+func readPayoutInput(path string) ([]*PayoutRow, error) {
+	records, err := readCSV(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取付款文件失败: %v", err)
+	}
+
+	var rows []*PayoutRow
+	for i, rec := range records {
+		if i == 0 && len(rec) > 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "address") {
+			continue
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("%s 第 %d 行格式错误: 至少需要 address,amount 两列", path, i+1)
+		}
+		row := &PayoutRow{
+			Line:    len(rows) + 1,
+			Address: strings.TrimSpace(rec[0]),
+			Amount:  strings.TrimSpace(rec[1]),
+			Status:  PayoutPending,
+		}
+		if len(rec) > 2 {
+			row.Token = strings.TrimSpace(rec[2])
+			if strings.EqualFold(row.Token, "ETH") {
+				row.Token = ""
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s 中没有付款记录", path)
+	}
+	return rows, nil
+}
+
+// mergePayoutResults 将上次运行的结果合并到输入行，输入内容变化时报错
+func mergePayoutResults(rows []*PayoutRow, path string) error {
+	records, err := readCSV(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, rec := range records {
+		if i == 0 {
+			continue // 表头
+		}
+		if len(rec) != len(payoutResultHeader) {
+			return fmt.Errorf("结果文件 %s 第 %d 行格式错误", path, i+1)
+		}
+		line, err := strconv.Atoi(rec[0])
+		if err != nil || line < 1 || line > len(rows) {
+			return fmt.Errorf("结果文件 %s 第 %d 行的行号无效", path, i+1)
+		}
+		row := rows[line-1]
+		if !strings.EqualFold(row.Address, rec[1]) || row.Amount != rec[2] || !strings.EqualFold(row.Token, rec[3]) {
+			return fmt.Errorf("输入文件第 %d 行与结果文件不一致，请确认没有修改已执行的批次", line)
+		}
+		row.Status, row.Nonce, row.TxHash, row.Block, row.GasUsed, row.Error = rec[4], rec[5], rec[6], rec[7], rec[8], rec[9]
+	}
+	Logger().Info("已加载上次运行结果", "file", path)
+	return nil
+}
+
+// resolvePayoutRows 校验地址、解析代币精度并换算金额
+func resolvePayoutRows(ctx context.Context, client Client, rows []*PayoutRow) error {
+	tokens := map[common.Address]*ERC20Info{}
+	var problems []string
+
+	for _, row := range rows {
+		to, checksummed, err := ParseChecksumAddress(row.Address)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("第 %d 行: %v", row.Line, err))
+			continue
+		}
+		if !checksummed {
+			Logger().Warn("地址不含校验和，无法检测输入错误", "line", row.Line, "address", row.Address)
+		}
+		row.to = to
+
+		var decimals uint8 = 18
+		if row.Token != "" {
+			tokenAddr, _, err := ParseChecksumAddress(row.Token)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("第 %d 行代币: %v", row.Line, err))
+				continue
+			}
+			info, ok := tokens[tokenAddr]
+			if !ok {
+				info, err = GetERC20Info(ctx, client, tokenAddr)
+				if err != nil {
+					return err
+				}
+				tokens[tokenAddr] = info
+			}
+			row.token = info
+			decimals = info.Decimals
+		}
+
+		row.value, err = ParseUnits(row.Amount, decimals)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("第 %d 行: %v", row.Line, err))
+			continue
+		}
+		if row.value.Sign() == 0 {
+			problems = append(problems, fmt.Sprintf("第 %d 行: 金额为 0", row.Line))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("付款文件校验失败:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// printPayoutSummary 打印待付款汇总，并检查账户余额是否足够
+func printPayoutSummary(ctx context.Context, client Client, from common.Address, chainID *big.Int, rows []*PayoutRow) error {
+	totals := map[string]*big.Int{}
+	decimals := map[string]uint8{}
+	counts := map[string]int{}
+	remaining := 0
+
+	for _, row := range rows {
+		counts[row.Status]++
+		if row.Status == PayoutConfirmed || row.Status == PayoutReverted {
+			continue
+		}
+		remaining++
+		key := ""
+		if !row.isETH() {
+			key = row.token.Address.Hex()
+			decimals[key] = row.token.Decimals
+		} else {
+			decimals[key] = 18
+		}
+		if totals[key] == nil {
+			totals[key] = new(big.Int)
+		}
+		totals[key].Add(totals[key], row.value)
+	}
+
+	fmt.Fprintln(TextOut(), "================ 批量付款汇总 ================")
+	fmt.Fprintf(TextOut(), "网络:       %s (链 ID %s)\n", ChainName(chainID), chainID)
+	fmt.Fprintf(TextOut(), "付款账户:   %s\n", from.Hex())
+	fmt.Fprintf(TextOut(), "总行数:     %d (已确认 %d, 已发送 %d, 结果未知 %d, 待发送 %d, 失败 %d, 回滚 %d)\n", len(rows),
+		counts[PayoutConfirmed], counts[PayoutSent], counts[PayoutUnknown], counts[PayoutPending], counts[PayoutFailed], counts[PayoutReverted])
+
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var insufficient []string
+	for _, key := range keys {
+		var balance *big.Int
+		var err error
+		name := "ETH"
+		if key == "" {
+			balance, err = client.BalanceAt(ctx, from, nil)
+		} else {
+			name = key
+			balance, err = GetERC20Balance(ctx, client, common.HexToAddress(key), from, BlockRef{})
+		}
+		if err != nil {
+			return fmt.Errorf("查询 %s 余额失败: %v", name, err)
+		}
+		fmt.Fprintf(TextOut(), "待付 %-44s %s (余额 %s)\n", name+":", FormatUnits(totals[key], decimals[key]), FormatUnits(balance, decimals[key]))
+		if balance.Cmp(totals[key]) < 0 {
+			insufficient = append(insufficient, name)
+		}
+	}
+	fmt.Fprintf(TextOut(), "待发送交易: 最多 %d 笔 (另需支付 Gas 费用)\n", remaining-counts[PayoutSent])
+	fmt.Fprintln(TextOut(), "==============================================")
+
+	if len(insufficient) > 0 {
+		return fmt.Errorf("余额不足: %s", strings.Join(insufficient, ", "))
+	}
+	return nil
+}
+
+// reconcileSentPayouts 核对上次已发送或发送结果未知的交易：已上链的更新状态，仍在交易池中的标记为已发送；
+// 节点查不到的交易只有在其 nonce 尚未被账户使用时才重新标记为待发送 (重发时会优先复用该 nonce，
+// 因此即使原交易稍后重新出现，两者也最多只有一笔上链)。nonce 已被使用却查不到回执时无法确认是否已付款，返回错误。
+func reconcileSentPayouts(ctx context.Context, client Client, from common.Address, rows []*PayoutRow) error {
+	var accountNonce *uint64
+	for _, row := range rows {
+		if row.Status != PayoutSent && row.Status != PayoutUnknown {
+			continue
+		}
+		hash := common.HexToHash(row.TxHash)
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			applyPayoutReceipt(row, receipt)
+			continue
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("查询第 %d 行交易回执失败: %v", row.Line, err)
+		}
+
+		_, isPending, err := client.TransactionByHash(ctx, hash)
+		if err == nil && isPending {
+			Logger().Info("交易仍在等待打包", "line", row.Line, logTx(hash))
+			row.Status = PayoutSent
+			row.Error = ""
+			continue
+		}
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("查询第 %d 行交易失败: %v", row.Line, err)
+		}
+
+		nonce, err := strconv.ParseUint(row.Nonce, 10, 64)
+		if err != nil {
+			return fmt.Errorf("第 %d 行交易 %s 的 nonce 无效 (%q)，无法确认是否已付款，请人工核对", row.Line, row.TxHash, row.Nonce)
+		}
+		if accountNonce == nil {
+			n, err := client.NonceAt(ctx, from, nil)
+			if err != nil {
+				return fmt.Errorf("获取账户 nonce 失败: %v", err)
+			}
+			accountNonce = &n
+		}
+		if nonce < *accountNonce {
+			return fmt.Errorf("第 %d 行交易 %s 查不到回执，但 nonce %d 已被使用，无法确认是否已付款，请在区块浏览器中人工核对后修改结果文件",
+				row.Line, row.TxHash, nonce)
+		}
+		Logger().Warn("交易未被节点接收或已从网络中消失，将重新发送", "line", row.Line, "nonce", nonce, logTx(hash))
+		row.Status = PayoutPending
+		row.Nonce, row.TxHash, row.Error = "", "", ""
+	}
+	return nil
+}
+
+func buildPayoutTx(ctx context.Context, client Client, from common.Address, chainID *big.Int, nonce uint64, gasTipCap, gasFeeCap *big.Int, row *PayoutRow) (*types.Transaction, error) {
+	if row.isETH() {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       21000,
+			To:        &row.to,
+			Value:     row.value,
+		}), nil
+	}
+
+	data, err := packERC20Transfer(row.to, row.value)
+	if err != nil {
+		return nil, fmt.Errorf("编码 transfer 调用失败: %v", err)
+	}
+	tokenAddr := row.token.Address
+	gas, err := client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &tokenAddr, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("估算 Gas 失败: %w", DecodeContractError(err, &parsedERC20ABI))
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gas * 12 / 10, // 预留 20% 余量
+		To:        &tokenAddr,
+		Value:     big.NewInt(0),
+		Data:      data,
+	}), nil
+}
+
+func applyPayoutReceipt(row *PayoutRow, receipt *types.Receipt) {
+	row.Block = receipt.BlockNumber.String()
+	row.GasUsed = strconv.FormatUint(receipt.GasUsed, 10)
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		row.Status = PayoutConfirmed
+		row.Error = ""
+	} else {
+		row.Status = PayoutReverted
+		row.Error = "交易执行失败 (status=0)"
+	}
+}
+
+// waitForReceipt 轮询等待交易回执并达到网络设置要求的确认数，直到上下文取消。
+// 每次轮询都重新获取回执，因此等待期间发生重组时返回的是最终所在区块的回执。
+func waitForReceipt(ctx context.Context, client Client, hash common.Hash) (*types.Receipt, error) {
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+
+	required := NetworkOf(client).RequiredConfirmations()
+	var reported uint64
+	for {
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil && required <= 1 {
+			return receipt, nil
+		}
+		if err == nil {
+			latest, err := client.BlockNumber(ctx)
+			if err != nil {
+				Logger().Warn("获取最新区块号失败", logErr(err))
+			} else if block := receipt.BlockNumber.Uint64(); latest >= block {
+				confirmations := latest - block + 1
+				if confirmations >= required {
+					return receipt, nil
+				}
+				if confirmations != reported {
+					Logger().Info("交易已上链，等待确认", logTx(hash), logBlock(block), "confirmations", confirmations, "required", required)
+					reported = confirmations
+				}
+			}
+		} else if !errors.Is(err, ethereum.NotFound) {
+			Logger().Warn("查询交易回执失败", logTx(hash), logErr(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *PayoutRow) CSVHeader() []string {
+	return payoutResultHeader
+}
+
+func (r *PayoutRow) CSVRow() []string {
+	return []string{strconv.Itoa(r.Line), r.Address, r.Amount, r.Token, r.Status, r.Nonce, r.TxHash, r.Block, r.GasUsed, r.Error}
+}
+
+// writePayoutResults 原子地写入结果文件 (先写临时文件再重命名)
+func writePayoutResults(path string, rows []*PayoutRow) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("创建结果文件失败: %v", err)
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write(payoutResultHeader)
+	for _, row := range rows {
+		_ = w.Write(row.CSVRow())
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("写入结果文件失败: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("写入结果文件失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func readCSV(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	var records [][]string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 CSV %s 失败: %v", path, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,122 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// lostResponseClient 把交易交给节点后总是返回超时，模拟节点已接收但每次响应都丢失
+type lostResponseClient struct {
+	Client
+}
+
+func (c lostResponseClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	_ = c.Client.SendTransaction(ctx, tx)
+	return context.DeadlineExceeded
+}
+
+type testRPCError struct{}
+
+func (testRPCError) Error() string  { return "insufficient funds for gas * price + value" }
+func (testRPCError) ErrorCode() int { return -32000 }
+
+func TestSendOutcomeUnknown(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: context.DeadlineExceeded, want: true},
+		{err: context.Canceled, want: true},
+		{err: errors.New("connection reset by peer"), want: true},
+		{err: rpc.HTTPError{StatusCode: 502}, want: true},
+		{err: rpc.HTTPError{StatusCode: 429}, want: false},
+		{err: fmt.Errorf("发送交易失败: %w", testRPCError{}), want: false},
+		{err: ErrCreditBudgetExceeded, want: false},
+	}
+	for _, tt := range tests {
+		if got := sendOutcomeUnknown(tt.err); got != tt.want {
+			t.Errorf("sendOutcomeUnknown(%v) = %v, 期望 %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRunPayoutDoesNotResendUnknownTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		from: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))},
+	})
+	defer backend.Close()
+	client := backend.Client()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			time.Sleep(50 * time.Millisecond)
+			backend.Commit()
+		}
+	}()
+
+	recipients := []common.Address{common.HexToAddress("0x1000000000000000000000000000000000000001"), common.HexToAddress("0x2000000000000000000000000000000000000002")}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "payouts.csv")
+	content := "address,amount\n" + recipients[0].Hex() + ",1.5\n" + recipients[1].Hex() + ",0.25\n"
+	if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := PayoutOptions{InputPath: input, AssumeYes: true}
+	privateKeyHex := fmt.Sprintf("%x", crypto.FromECDSA(key))
+
+	// 第一次运行：第一笔交易已被节点接收但响应丢失，应记录为结果未知并停止发送
+	err := RunPayout(ctx, lostResponseClient{client}, privateKeyHex, opts)
+	if err == nil || !strings.Contains(err.Error(), "结果未知") {
+		t.Fatalf("期望返回结果未知的错误，得到 %v", err)
+	}
+	result, err := os.ReadFile(strings.TrimSuffix(input, ".csv") + ".result.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(result)), "\n")
+	if !strings.Contains(lines[1], ","+PayoutUnknown+",0,0x") {
+		t.Fatalf("第 1 行应记录为 unknown 且包含 nonce 与交易哈希: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], ","+PayoutPending+",") {
+		t.Fatalf("第 2 行应保持 pending: %s", lines[2])
+	}
+
+	// 第二次运行：核对第一笔交易而不是重新签名，只发送第二笔
+	if err := RunPayout(ctx, client, privateKeyHex, opts); err != nil {
+		t.Fatalf("重新运行失败: %v", err)
+	}
+	want := []string{"1500000000000000000", "250000000000000000"}
+	for i, addr := range recipients {
+		balance, err := client.BalanceAt(ctx, addr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.String() != want[i] {
+			t.Errorf("%s 余额 = %s, 期望 %s", addr.Hex(), balance, want[i])
+		}
+	}
+	nonce, err := client.NonceAt(ctx, from, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 2 {
+		t.Errorf("付款账户 nonce = %d, 期望 2 (不应重复发送)", nonce)
+	}
+}
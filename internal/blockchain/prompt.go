@@ -0,0 +1,47 @@
+package blockchain
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// MainnetChainID 以太坊主网的链 ID
+const MainnetChainID = 1
+
+// IsMainnet 判断链 ID 是否为以太坊主网
+func IsMainnet(chainID *big.Int) bool {
+	return chainID != nil && chainID.IsUint64() && chainID.Uint64() == MainnetChainID
+}
+
+// Confirm 在终端打印提示并等待用户输入确认词 (区分大小写)。
+// 只有输入与 expected 完全一致时才返回 true。
+func Confirm(prompt string, expected string) bool {
+	fmt.Fprintf(TextOut(), "%s 请输入 %q 确认: ", prompt, expected)
+	reader := bufio.NewReader(os.Stdin)
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	return strings.TrimSpace(line) == expected
+}
+
+// ConfirmMainnetWrite 在主网上执行写操作前打印醒目的警告，并要求输入 "MAINNET" 确认。
+// 非主网直接返回 true；assumeYes 时只打印警告不等待输入 (用于脚本)。
+func ConfirmMainnetWrite(chainID *big.Int, action string, assumeYes bool) bool {
+	if !IsMainnet(chainID) {
+		return true
+	}
+	fmt.Fprintln(TextOut(), "################################################")
+	fmt.Fprintln(TextOut(), "##  警告: 当前连接的是以太坊主网 (链 ID 1)")
+	fmt.Fprintln(TextOut(), "##  交易将花费真实的 ETH，且无法撤销")
+	fmt.Fprintln(TextOut(), "################################################")
+	fmt.Fprintf(TextOut(), "操作: %s\n", action)
+	if assumeYes {
+		fmt.Fprintln(TextOut(), "已通过 -yes 跳过主网确认")
+		return true
+	}
+	return Confirm("确定要在主网上继续吗？", "MAINNET")
+}
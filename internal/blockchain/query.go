@@ -3,23 +3,25 @@ package blockchain
 import (
 	"context"
 	"fmt"
-	"log"
 	"math/big"
 	"time"
-
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 // QueryBlockInfo 查询并打印区块信息
-func QueryBlockInfo(client *ethclient.Client, blockNumber int64) {
+func QueryBlockInfo(client Client, blockNumber int64) error {
 	block, err := client.BlockByNumber(context.Background(), big.NewInt(blockNumber))
 	if err != nil {
-		log.Fatalf("获取区块失败: %v", err)
+		return fmt.Errorf("获取区块失败: %v", err)
+	}
+
+	if !IsTextOutput() {
+		return Emit(NewBlockRecord(block))
 	}
 
-	fmt.Printf("区块号: %d\n", block.Number().Uint64())
-	fmt.Printf("区块哈希: %s\n", block.Hash().Hex())
-	fmt.Printf("区块时间戳: %s\n", time.Unix(int64(block.Time()), 0))
-	fmt.Printf("交易数量: %d\n", len(block.Transactions()))
-	fmt.Println("--------------------------------------------------")
+	fmt.Fprintf(TextOut(), "区块号: %d\n", block.Number().Uint64())
+	fmt.Fprintf(TextOut(), "区块哈希: %s\n", block.Hash().Hex())
+	fmt.Fprintf(TextOut(), "区块时间戳: %s\n", time.Unix(int64(block.Time()), 0))
+	fmt.Fprintf(TextOut(), "交易数量: %d\n", len(block.Transactions()))
+	fmt.Fprintln(TextOut(), "--------------------------------------------------")
+	return nil
 }
@@ -0,0 +1,497 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 限流参数
+const (
+	defaultMethodCost   = 80
+	maxRateLimitRetries = 5
+	maxRateLimitBackoff = 30 * time.Second
+	rateLimitErrorCode  = -32005 // Infura 等节点服务商返回的限流错误码
+)
+
+// ErrCreditBudgetExceeded 本次运行消耗的积分超过预算
+var ErrCreditBudgetExceeded = errors.New("超出本次运行的积分预算")
+
+// methodCosts 各 RPC 方法的积分权重，参考 Infura 积分表，未列出的方法按 defaultMethodCost 计算
+var methodCosts = map[string]float64{
+	"eth_chainId":               5,
+	"net_version":               5,
+	"eth_subscribe":             5,
+	"eth_unsubscribe":           10,
+	"eth_blockNumber":           80,
+	"eth_getBalance":            80,
+	"eth_getCode":               80,
+	"eth_getStorageAt":          80,
+	"eth_getTransactionCount":   80,
+	"eth_call":                  80,
+	"eth_gasPrice":              80,
+	"eth_maxPriorityFeePerGas":  80,
+	"eth_feeHistory":            80,
+	"eth_getBlockByNumber":      80,
+	"eth_getBlockByHash":        80,
+	"eth_getTransactionByHash":  80,
+	"eth_getTransactionReceipt": 80,
+	"eth_getLogs":               255,
+	"eth_estimateGas":           300,
+	"eth_sendRawTransaction":    720,
+	"eth_getBlockReceipts":      1000,
+}
+
+// MethodCost 返回 RPC 方法的积分权重
+func MethodCost(method string) float64 {
+	if cost, ok := methodCosts[method]; ok {
+		return cost
+	}
+	return defaultMethodCost
+}
+
+// MethodUsage 单个 RPC 方法在本次运行中的请求数与消耗积分
+type MethodUsage struct {
+	Method   string  `json:"method"`
+	Requests int     `json:"requests"`
+	Credits  float64 `json:"credits"`
+}
+
+// RateLimiter 按积分计费的令牌桶限流器。
+// 每个请求按方法权重扣除积分，积分按 creditsPerSecond 匀速恢复；
+// 节点返回 429 或限流错误时暂停所有请求，直到 Retry-After 指定的时间过去。
+type RateLimiter struct {
+	rate   float64 // 每秒恢复的积分，0 表示不限速 (仍统计积分)
+	burst  float64
+	budget float64 // 本次运行的积分上限，0 表示不限
+
+	mu           sync.Mutex
+	tokens       float64
+	last         time.Time
+	backoffUntil time.Time
+	usage        map[string]*MethodUsage
+	total        float64 // 已被节点接受的请求消耗的积分
+	pending      float64 // 已放行但尚未完成的请求预占的积分，计入预算检查
+	throttled    int
+	waited       time.Duration
+}
+
+// NewRateLimiter 创建限流器。creditsPerSecond 为 0 时不限速；budget 为 0 时不限制总积分。
+// 桶容量为一秒的积分，超过容量的单个请求 (如 eth_getBlockReceipts) 在桶满时放行并透支。
+func NewRateLimiter(creditsPerSecond, budget float64) *RateLimiter {
+	return &RateLimiter{
+		rate:   creditsPerSecond,
+		burst:  creditsPerSecond,
+		budget: budget,
+		tokens: creditsPerSecond,
+		last:   time.Now(),
+		usage:  make(map[string]*MethodUsage),
+	}
+}
+
+// methodsCost 返回一次请求 (批量请求包含多个方法) 的积分
+func methodsCost(methods []string) float64 {
+	var cost float64
+	for _, m := range methods {
+		cost += MethodCost(m)
+	}
+	return cost
+}
+
+// Wait 阻塞直到有足够积分发送 methods (批量请求包含多个方法)。
+// 返回 nil 时已为该请求预占预算，调用方必须在请求结束后调用 record 或 release。
+func (l *RateLimiter) Wait(ctx context.Context, methods []string) error {
+	cost := methodsCost(methods)
+	start := time.Now()
+	for {
+		delay, err := l.reserve(cost)
+		if err != nil {
+			return err
+		}
+		if delay <= 0 {
+			l.mu.Lock()
+			l.waited += time.Since(start)
+			l.mu.Unlock()
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// reserve 尝试扣除积分并预占预算，不足时返回需要等待的时间。
+// 预算检查包含其他并发请求已预占的积分，因此同时放行的请求总和不会超出预算。
+func (l *RateLimiter) reserve(cost float64) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.budget > 0 && l.total+l.pending+cost > l.budget {
+		return 0, fmt.Errorf("%w (已消耗 %.0f，进行中 %.0f / %.0f)", ErrCreditBudgetExceeded, l.total, l.pending, l.budget)
+	}
+	now := time.Now()
+	if now.Before(l.backoffUntil) {
+		return l.backoffUntil.Sub(now), nil
+	}
+	if l.rate > 0 {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		need := min(cost, l.burst)
+		if l.tokens < need {
+			return time.Duration((need - l.tokens) / l.rate * float64(time.Second)), nil
+		}
+		l.tokens -= cost
+	}
+	l.pending += cost
+	return 0, nil
+}
+
+// release 释放请求预占的预算 (请求失败或被限流，未被节点接受)
+func (l *RateLimiter) release(methods []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = max(0, l.pending-methodsCost(methods))
+}
+
+// record 记录已被节点接受的请求，并将预占的预算转为已消耗
+func (l *RateLimiter) record(methods []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = max(0, l.pending-methodsCost(methods))
+	for _, m := range methods {
+		u, ok := l.usage[m]
+		if !ok {
+			u = &MethodUsage{Method: m}
+			l.usage[m] = u
+		}
+		u.Requests++
+		u.Credits += MethodCost(m)
+		l.total += MethodCost(m)
+	}
+}
+
+// throttle 节点限流后暂停所有请求 delay 时长
+func (l *RateLimiter) throttle(delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.throttled++
+	if until := time.Now().Add(delay); until.After(l.backoffUntil) {
+		l.backoffUntil = until
+	}
+}
+
+// Usage 返回各方法的积分消耗，按消耗从高到低排列
+func (l *RateLimiter) Usage() []MethodUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	usage := make([]MethodUsage, 0, len(l.usage))
+	for _, u := range l.usage {
+		usage = append(usage, *u)
+	}
+	slices.SortFunc(usage, func(a, b MethodUsage) int {
+		if a.Credits != b.Credits {
+			if a.Credits > b.Credits {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return usage
+}
+
+// LogCreditUsage 打印本次运行的积分消耗；detail 为 true 时逐个方法列出
+func LogCreditUsage(l *RateLimiter, detail bool) {
+	if l == nil {
+		return
+	}
+	usage := l.Usage()
+	if len(usage) == 0 {
+		return
+	}
+	l.mu.Lock()
+	total, throttled, waited := l.total, l.throttled, l.waited
+	l.mu.Unlock()
+
+	var requests int
+	for _, u := range usage {
+		requests += u.Requests
+	}
+	Logger().Info("RPC 积分统计", "requests", requests, "credits", math.Round(total), "throttled", throttled, "waited", waited.Round(time.Millisecond))
+	if detail {
+		for _, u := range usage {
+			Logger().Info("RPC 方法积分", "method", u.Method, "requests", u.Requests, "credits", math.Round(u.Credits))
+		}
+	}
+}
+
+// rateLimitTransport 在 HTTP 层对 JSON-RPC 请求限速，并在节点限流时按 Retry-After 退避重试。
+// 作为 FailoverTransport 的底层传输，因此健康检查也计入积分；重试耗尽后由故障转移切换节点。
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	methods := rpcMethods(body)
+
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(req.Context(), methods); err != nil {
+			return nil, err
+		}
+		out := req.Clone(req.Context())
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+
+		resp, err := t.base.RoundTrip(out)
+		if err != nil {
+			t.limiter.release(methods)
+			return nil, err
+		}
+		delay, limited, err := checkRateLimited(resp)
+		if err != nil {
+			t.limiter.release(methods)
+			return nil, err
+		}
+		if !limited {
+			t.limiter.record(methods)
+			return resp, nil
+		}
+		t.limiter.release(methods)
+		delay = rateLimitBackoff(delay, attempt)
+		t.limiter.throttle(delay)
+		if attempt >= maxRateLimitRetries {
+			return resp, nil
+		}
+		Logger().Warn("节点限流，稍后重试", logEndpoint(req.URL.String()), "methods", strings.Join(methods, ","),
+			"delay", delay.Round(time.Millisecond), logAttempt(attempt+1), "max_attempts", maxRateLimitRetries)
+	}
+}
+
+// rpcMethods 解析 JSON-RPC 请求体 (单个或批量) 中的方法名
+func rpcMethods(body []byte) []string {
+	type call struct {
+		Method string `json:"method"`
+	}
+	var batch []call
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var single call
+		if err := json.Unmarshal(body, &single); err != nil || single.Method == "" {
+			return []string{"unknown"}
+		}
+		batch = []call{single}
+	}
+	methods := make([]string, len(batch))
+	for i, c := range batch {
+		methods[i] = c.Method
+	}
+	return methods
+}
+
+// checkRateLimited 判断响应是否为限流：HTTP 429，或 HTTP 200 中包含限流错误的 JSON-RPC 响应。
+// 响应体会被完整读取并替换为可重复读取的副本。返回的 delay 为节点建议的等待时间 (未指定时为 0)。
+func checkRateLimited(resp *http.Response) (time.Duration, bool, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return 0, false, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return parseRetryAfter(resp.Header.Get("Retry-After")), true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, nil
+	}
+
+	type rpcError struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	type message struct {
+		Error *rpcError `json:"error"`
+	}
+	var batch []message
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var single message
+		if json.Unmarshal(body, &single) != nil {
+			return 0, false, nil
+		}
+		batch = []message{single}
+	}
+	for _, m := range batch {
+		if m.Error == nil || !isRateLimitMessage(m.Error.Code, m.Error.Message) {
+			continue
+		}
+		var data struct {
+			BackoffSeconds float64 `json:"backoff_seconds"`
+		}
+		_ = json.Unmarshal(m.Error.Data, &data)
+		return time.Duration(data.BackoffSeconds * float64(time.Second)), true, nil
+	}
+	return 0, false, nil
+}
+
+// isRateLimitMessage 根据 JSON-RPC 错误码和错误信息判断是否为限流
+func isRateLimitMessage(code int, message string) bool {
+	if code == rateLimitErrorCode {
+		return true
+	}
+	msg := strings.ToLower(message)
+	return strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests") ||
+		strings.Contains(msg, "request count exceeded")
+}
+
+// rateLimitError 判断 WebSocket 调用返回的错误是否为限流，并提取节点建议的等待时间
+func rateLimitError(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	var rpcErr rpc.Error
+	code := 0
+	if errors.As(err, &rpcErr) {
+		code = rpcErr.ErrorCode()
+	}
+	if !isRateLimitMessage(code, err.Error()) {
+		return 0, false
+	}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(map[string]interface{}); ok {
+			if seconds, ok := data["backoff_seconds"].(float64); ok {
+				return time.Duration(seconds * float64(time.Second)), true
+			}
+		}
+	}
+	return 0, true
+}
+
+// parseRetryAfter 解析 Retry-After 头 (秒数或 HTTP 日期)，无法解析时返回 0
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// rateLimitPolicy 节点未给出等待时间时的退避策略 (1s、2s、4s... 加随机抖动)
+var rateLimitPolicy = RetryPolicy{
+	MaxAttempts: maxRateLimitRetries + 1,
+	BaseDelay:   time.Second,
+	MaxDelay:    maxRateLimitBackoff,
+	Multiplier:  2,
+	Jitter:      0.3,
+}
+
+// rateLimitBackoff 优先使用节点建议的等待时间，否则按 rateLimitPolicy 退避，并限制最长等待
+func rateLimitBackoff(suggested time.Duration, attempt int) time.Duration {
+	if suggested <= 0 {
+		return rateLimitPolicy.Backoff(attempt)
+	}
+	return min(suggested, maxRateLimitBackoff)
+}
+
+// rateLimitedClient 为 WebSocket 客户端的查询与订阅调用加上限速和限流重试。
+// WebSocket 无法在传输层拦截请求，因此只覆盖订阅模式实际使用的方法。
+type rateLimitedClient struct {
+	*ethclient.Client
+	limiter *RateLimiter
+}
+
+// limitClient 为 WebSocket 客户端包装限流器；limiter 为 nil 时原样返回
+func limitClient(client *ethclient.Client, limiter *RateLimiter) Client {
+	if limiter == nil {
+		return client
+	}
+	return &rateLimitedClient{Client: client, limiter: limiter}
+}
+
+// callLimited 按 method 的权重限速执行 call，节点限流时退避重试
+func callLimited[T any](ctx context.Context, l *RateLimiter, method string, call func() (T, error)) (T, error) {
+	methods := []string{method}
+	for attempt := 0; ; attempt++ {
+		if err := l.Wait(ctx, methods); err != nil {
+			var zero T
+			return zero, err
+		}
+		v, err := call()
+		delay, limited := rateLimitError(err)
+		if !limited {
+			l.record(methods)
+			return v, err
+		}
+		l.release(methods)
+		delay = rateLimitBackoff(delay, attempt)
+		l.throttle(delay)
+		if attempt >= maxRateLimitRetries {
+			return v, err
+		}
+		Logger().Warn("WebSocket 节点限流，稍后重试", "methods", method, "delay", delay.Round(time.Millisecond), logAttempt(attempt+1), "max_attempts", maxRateLimitRetries)
+	}
+}
+
+func (c *rateLimitedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return callLimited(ctx, c.limiter, "eth_getBlockByNumber", func() (*types.Header, error) {
+		return c.Client.HeaderByNumber(ctx, number)
+	})
+}
+
+func (c *rateLimitedClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return callLimited(ctx, c.limiter, "eth_feeHistory", func() (*ethereum.FeeHistory, error) {
+		return c.Client.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+	})
+}
+
+func (c *rateLimitedClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return callLimited(ctx, c.limiter, "eth_getLogs", func() ([]types.Log, error) {
+		return c.Client.FilterLogs(ctx, q)
+	})
+}
+
+func (c *rateLimitedClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return callLimited(ctx, c.limiter, "eth_subscribe", func() (ethereum.Subscription, error) {
+		return c.Client.SubscribeNewHead(ctx, ch)
+	})
+}
+
+func (c *rateLimitedClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return callLimited(ctx, c.limiter, "eth_subscribe", func() (ethereum.Subscription, error) {
+		return c.Client.SubscribeFilterLogs(ctx, q, ch)
+	})
+}
@@ -0,0 +1,55 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRateLimiterBudgetReservesConcurrentRequests(t *testing.T) {
+	// 预算只够 2 次 eth_call (每次 80 积分)，请求尚未完成时其他调用方也不能越过预算
+	l := NewRateLimiter(0, 200)
+	methods := []string{"eth_call"}
+
+	var mu sync.Mutex
+	admitted, rejected := 0, 0
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := l.Wait(context.Background(), methods)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				admitted++
+			case errors.Is(err, ErrCreditBudgetExceeded):
+				rejected++
+			default:
+				t.Errorf("意外的错误: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if admitted != 2 || rejected != 8 {
+		t.Fatalf("放行 %d 个、拒绝 %d 个，期望 2 个、8 个", admitted, rejected)
+	}
+
+	// 未被节点接受的请求释放预占后，预算可再次使用
+	l.release(methods)
+	if err := l.Wait(context.Background(), methods); err != nil {
+		t.Fatalf("释放预占后应放行，得到 %v", err)
+	}
+
+	// 被接受的请求计入已消耗，预算不会因此重复释放
+	l.record(methods)
+	l.record(methods)
+	if err := l.Wait(context.Background(), methods); !errors.Is(err, ErrCreditBudgetExceeded) {
+		t.Fatalf("已消耗 160 积分后再请求 80 积分应超出预算，得到 %v", err)
+	}
+	if l.pending != 0 || l.total != 160 {
+		t.Errorf("pending = %v, total = %v，期望 0 和 160", l.pending, l.total)
+	}
+}
@@ -0,0 +1,162 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultRegistryPath 部署记录文件的默认路径
+const DefaultRegistryPath = "deployments.json"
+
+// Deployment 一次合约部署的记录
+type Deployment struct {
+	Name         string    `json:"name"`              // 部署名称，供 -contract 引用
+	Contract     string    `json:"contract"`          // 合约类型，如 Counter
+	Network      string    `json:"network"`           // 网络名称
+	ChainID      uint64    `json:"chainId"`           // 链 ID
+	Address      string    `json:"address"`           // 合约地址
+	TxHash       string    `json:"txHash"`            // 部署交易哈希
+	Block        uint64    `json:"block"`             // 部署区块
+	Deployer     string    `json:"deployer"`          // 部署账户
+	BytecodeHash string    `json:"bytecodeHash"`      // 链上运行时代码的 keccak256
+	Salt         string    `json:"salt,omitempty"`    // CREATE2 盐值
+	Factory      string    `json:"factory,omitempty"` // CREATE2 工厂地址
+	DeployedAt   time.Time `json:"deployedAt"`
+}
+
+func (d *Deployment) CSVHeader() []string {
+	return []string{"name", "contract", "network", "chain_id", "address", "tx_hash", "block", "deployer", "bytecode_hash", "salt", "factory", "deployed_at"}
+}
+
+func (d *Deployment) CSVRow() []string {
+	return []string{d.Name, d.Contract, d.Network, strconv.FormatUint(d.ChainID, 10), d.Address, d.TxHash, strconv.FormatUint(d.Block, 10),
+		d.Deployer, d.BytecodeHash, d.Salt, d.Factory, d.DeployedAt.Format(time.RFC3339)}
+}
+
+// Registry 本地部署记录文件
+type Registry struct {
+	Deployments []Deployment `json:"deployments"`
+}
+
+// LoadRegistry 读取部署记录文件，文件不存在时返回空记录
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Registry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取部署记录 %s 失败: %v", path, err)
+	}
+
+	var reg Registry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("解析部署记录 %s 失败: %v", path, err)
+	}
+	return &reg, nil
+}
+
+// Save 将部署记录写回文件 (先写临时文件再重命名)
+func (r *Registry) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化部署记录失败: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入部署记录失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Add 追加一条部署记录。同一网络上的同名部署保留历史，查询时以最新一条为准。
+// 若已存在名称、链 ID 和地址都相同的记录 (如 CREATE2 重复部署被跳过)，则不重复追加。
+func (r *Registry) Add(d Deployment) {
+	for _, existing := range r.Deployments {
+		if existing.Name == d.Name && existing.ChainID == d.ChainID && strings.EqualFold(existing.Address, d.Address) {
+			return
+		}
+	}
+	r.Deployments = append(r.Deployments, d)
+}
+
+// Lookup 按名称查找部署记录。
+// chainID 不为 nil 时只匹配该链；为 nil 时要求名称在所有网络中唯一。
+func (r *Registry) Lookup(name string, chainID *big.Int) (*Deployment, error) {
+	var found *Deployment
+	networks := map[uint64]bool{}
+	for i := range r.Deployments {
+		d := &r.Deployments[i]
+		if d.Name != name {
+			continue
+		}
+		if chainID != nil && (!chainID.IsUint64() || d.ChainID != chainID.Uint64()) {
+			continue
+		}
+		networks[d.ChainID] = true
+		found = d // 后追加的记录更新
+	}
+
+	if found == nil {
+		if chainID != nil {
+			return nil, fmt.Errorf("部署记录中没有链 ID %s 上名为 %q 的合约", chainID, name)
+		}
+		return nil, fmt.Errorf("部署记录中没有名为 %q 的合约", name)
+	}
+	if len(networks) > 1 {
+		return nil, fmt.Errorf("名称 %q 在多个网络上都有部署，请直接使用合约地址", name)
+	}
+	return found, nil
+}
+
+// ResolveContractAddress 解析 -contract 参数：十六进制地址原样返回，否则按名称在部署记录中查找。
+func ResolveContractAddress(registryPath string, nameOrAddress string, chainID *big.Int) (string, error) {
+	if common.IsHexAddress(nameOrAddress) {
+		return nameOrAddress, nil
+	}
+	reg, err := LoadRegistry(registryPath)
+	if err != nil {
+		return "", err
+	}
+	d, err := reg.Lookup(nameOrAddress, chainID)
+	if err != nil {
+		return "", err
+	}
+	return d.Address, nil
+}
+
+// PrintDeployments 打印部署记录
+func PrintDeployments(reg *Registry) {
+	if !IsTextOutput() {
+		for i := range reg.Deployments {
+			emitOrWarn(&reg.Deployments[i])
+		}
+		return
+	}
+	if len(reg.Deployments) == 0 {
+		fmt.Fprintln(TextOut(), "暂无部署记录")
+		return
+	}
+	for _, d := range reg.Deployments {
+		fmt.Fprintln(TextOut(), "------------------------------------------------")
+		fmt.Fprintf(TextOut(), "名称:       %s (%s)\n", d.Name, d.Contract)
+		fmt.Fprintf(TextOut(), "网络:       %s (链 ID %d)\n", d.Network, d.ChainID)
+		fmt.Fprintf(TextOut(), "合约地址:   %s\n", d.Address)
+		fmt.Fprintf(TextOut(), "交易哈希:   %s\n", d.TxHash)
+		fmt.Fprintf(TextOut(), "区块号:     %d\n", d.Block)
+		fmt.Fprintf(TextOut(), "部署账户:   %s\n", d.Deployer)
+		fmt.Fprintf(TextOut(), "代码哈希:   %s\n", d.BytecodeHash)
+		if d.Salt != "" {
+			fmt.Fprintf(TextOut(), "CREATE2:    工厂 %s, 盐值 %s\n", d.Factory, d.Salt)
+		}
+		fmt.Fprintf(TextOut(), "部署时间:   %s\n", d.DeployedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintln(TextOut(), "------------------------------------------------")
+}
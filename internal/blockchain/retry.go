@@ -0,0 +1,216 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RetryPolicy 重试策略：指数退避加随机抖动，只重试 Retryable 判定为临时性的错误
+type RetryPolicy struct {
+	MaxAttempts int              // 最多尝试次数 (包括第一次)，0 表示不限次数
+	BaseDelay   time.Duration    // 第一次重试前的等待时间
+	MaxDelay    time.Duration    // 单次等待的上限
+	Multiplier  float64          // 每次重试等待时间的倍数
+	Jitter      float64          // 随机抖动比例 (0-1)，等待时间在 [d*(1-Jitter), d] 之间均匀分布
+	Retryable   func(error) bool // 错误分类，nil 时使用 IsRetryable
+}
+
+// DefaultRetryPolicy 读取操作 (区块扫描、状态查询) 的默认策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    15 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.3,
+}
+
+// WriteRetryPolicy 发送交易的策略：次数较少，已被节点接收的交易不会重复发送 (见 sendTransactionWithRetry)
+var WriteRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Second,
+	MaxDelay:    10 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.3,
+}
+
+// ReconnectPolicy WebSocket 订阅断线重连的策略：不限次数，连接成功后重新计数
+var ReconnectPolicy = RetryPolicy{
+	BaseDelay:  time.Second,
+	MaxDelay:   30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.3,
+}
+
+// Backoff 返回第 attempt 次重试 (从 0 开始) 前的等待时间
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(max(p.Multiplier, 1), float64(attempt))
+	if p.MaxDelay > 0 {
+		d = min(d, float64(p.MaxDelay))
+	}
+	if p.Jitter > 0 {
+		d -= d * min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// Sleep 等待第 attempt 次重试的退避时间，ctx 取消时返回 false
+func (p RetryPolicy) Sleep(ctx context.Context, attempt int) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(p.Backoff(attempt)):
+		return true
+	}
+}
+
+// RetryNotFound 返回同时重试 ethereum.NotFound 的策略，只用于按区块号或哈希读取区块与区块头：
+// 负载均衡后的节点可能尚未同步到刚出现的区块
+func (p RetryPolicy) RetryNotFound() RetryPolicy {
+	retryable := p.retryable
+	p.Retryable = func(err error) bool {
+		return errors.Is(err, ethereum.NotFound) || retryable(err)
+	}
+	return p
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// Do 执行 fn，遇到可重试的错误时按策略退避重试；op 与 attrs (如 block、tx 字段) 用于日志
+func (p RetryPolicy) Do(ctx context.Context, op string, fn func() error, attrs ...any) error {
+	_, err := retryValue(ctx, p, op, func() (struct{}, error) {
+		return struct{}{}, fn()
+	}, attrs...)
+	return err
+}
+
+// retryValue 是 RetryPolicy.Do 的带返回值版本
+func retryValue[T any](ctx context.Context, p RetryPolicy, op string, fn func() (T, error), attrs ...any) (T, error) {
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || ctx.Err() != nil || !p.retryable(err) {
+			return v, err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return v, err
+		}
+		delay := p.Backoff(attempt - 1)
+		Logger().With(attrs...).Warn(op+"失败，稍后重试", logAttempt(attempt), "max_attempts", p.MaxAttempts, "delay", delay.Round(time.Millisecond), logErr(err))
+		select {
+		case <-ctx.Done():
+			return v, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// IsRetryable 判断错误是否为临时性错误：网络错误、超时、HTTP 5xx / 408 / 429、节点限流，
+// 以及负载均衡节点之间同步延迟导致的 "header not found" 等。
+// 合约 revert、参数错误、余额不足、预算耗尽和调用方取消均不重试；
+// ethereum.NotFound 默认也不重试 (交易、回执不存在是确定结果)，读取区块时使用 RetryNotFound。
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCreditBudgetExceeded) || errors.Is(err, ErrHistoricalStateUnavailable) {
+		return false
+	}
+	var contractErr *ContractError
+	if errors.As(err, &contractErr) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == 408 || httpErr.StatusCode == 429
+	}
+	if _, limited := rateLimitError(err); limited {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"header not found", "timeout", "connection reset", "connection refused", "broken pipe",
+		"所有 rpc 节点均不可用", "http 5", "bad gateway", "service unavailable", "gateway timeout"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownTxError 节点已收到同一笔交易 (如上一次发送的响应丢失)
+func isKnownTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// sendOutcomeUnknown 判断发送失败后交易是否可能已被节点接收：
+// 节点返回 JSON-RPC 错误 (如余额不足、nonce 过低) 或 HTTP 4xx、以及本地预算耗尽时交易一定未被接收；
+// 超时、断线、5xx 等情况下请求可能已到达节点，只是响应丢失。
+func sendOutcomeUnknown(err error) bool {
+	if errors.Is(err, ErrCreditBudgetExceeded) {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	return true
+}
+
+// sendTransactionWithRetry 按 WriteRetryPolicy 发送已签名交易。
+// 签名交易的哈希是确定的，重发同一笔交易是安全的：节点返回 "already known"，
+// 或上一次发送已上链导致 "nonce too low" 且能查到该交易时，均视为发送成功。
+func sendTransactionWithRetry(ctx context.Context, client Client, tx *types.Transaction) error {
+	attempts := 0
+	return WriteRetryPolicy.Do(ctx, "发送交易", func() error {
+		attempts++
+		err := client.SendTransaction(ctx, tx)
+		if err == nil || attempts == 1 {
+			return err
+		}
+		if isKnownTxError(err) {
+			return nil
+		}
+		if strings.Contains(strings.ToLower(err.Error()), "nonce too low") {
+			if _, _, lookupErr := client.TransactionByHash(ctx, tx.Hash()); lookupErr == nil {
+				return nil
+			}
+		}
+		return err
+	}, logTx(tx.Hash()))
+}
+
+// retrySendClient 为合约绑定 (bind) 发出的交易加上 sendTransactionWithRetry 的重试逻辑
+type retrySendClient struct {
+	Client
+}
+
+func (c retrySendClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return sendTransactionWithRetry(ctx, c.Client, tx)
+}
@@ -0,0 +1,50 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err           error
+		want          bool
+		wantBlockRead bool // 使用 RetryNotFound 时的结果
+	}{
+		{err: nil},
+		{err: context.DeadlineExceeded, want: true, wantBlockRead: true},
+		{err: io.ErrUnexpectedEOF, want: true, wantBlockRead: true},
+		{err: rpc.HTTPError{StatusCode: 502}, want: true, wantBlockRead: true},
+		{err: rpc.HTTPError{StatusCode: 429}, want: true, wantBlockRead: true},
+		{err: rpc.HTTPError{StatusCode: 400}},
+		{err: errors.New("header not found"), want: true, wantBlockRead: true},
+		{err: ethereum.NotFound, wantBlockRead: true},
+		{err: fmt.Errorf("获取区块: %w", ethereum.NotFound), wantBlockRead: true},
+		{err: context.Canceled},
+		{err: ErrCreditBudgetExceeded},
+		{err: errors.New("insufficient funds for gas * price + value")},
+	}
+	blockRead := DefaultRetryPolicy.RetryNotFound()
+	for _, tt := range tests {
+		if got := DefaultRetryPolicy.retryable(tt.err); got != tt.want {
+			t.Errorf("retryable(%v) = %v, 期望 %v", tt.err, got, tt.want)
+		}
+		if got := blockRead.retryable(tt.err); got != tt.wantBlockRead {
+			t.Errorf("RetryNotFound().retryable(%v) = %v, 期望 %v", tt.err, got, tt.wantBlockRead)
+		}
+	}
+}
+
+func TestRetryNotFoundKeepsCustomClassifier(t *testing.T) {
+	custom := errors.New("custom")
+	p := RetryPolicy{Retryable: func(err error) bool { return errors.Is(err, custom) }}.RetryNotFound()
+	if !p.retryable(custom) || !p.retryable(ethereum.NotFound) || p.retryable(context.DeadlineExceeded) {
+		t.Error("RetryNotFound 应在原有分类的基础上只增加 NotFound")
+	}
+}
@@ -3,20 +3,32 @@ package blockchain
 import (
 	"context"
 	"fmt"
-	"log"
 	"math/big"
-	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// ScanOptions 区块扫描选项
+type ScanOptions struct {
+	Retry     RetryPolicy // 获取区块头的重试策略
+	NeverSkip bool        // 重试耗尽后返回错误停止扫描，而不是跳过该区块
+}
+
 // ScanBlocks 扫描指定范围的区块头并调用回调函数处理
 // start: 起始区块号 (包含)
 // end: 结束区块号 (包含)
 // onBlock: 处理每个区块的回调函数
-func ScanBlocks(ctx context.Context, client *ethclient.Client, start int64, end int64, onBlock func(*types.Header)) error {
-	log.Printf("开始扫描区块: %d -> %d", start, end)
+// 获取失败的区块按 DefaultRetryPolicy 重试，重试耗尽后跳过；需要保证不遗漏区块时使用 ScanBlocksWith 并设置 NeverSkip。
+func ScanBlocks(ctx context.Context, client Client, start int64, end int64, onBlock func(*types.Header)) error {
+	return ScanBlocksWith(ctx, client, start, end, ScanOptions{Retry: DefaultRetryPolicy}, onBlock)
+}
+
+// ScanBlocksWith 按 opts 扫描指定范围的区块头，opts.Retry 为零值时使用 DefaultRetryPolicy
+func ScanBlocksWith(ctx context.Context, client Client, start int64, end int64, opts ScanOptions, onBlock func(*types.Header)) error {
+	if opts.Retry.MaxAttempts == 0 && opts.Retry.BaseDelay == 0 {
+		opts.Retry = DefaultRetryPolicy
+	}
+	Logger().Info("开始扫描区块", "start", start, "end", end)
 
 	for i := start; i <= end; i++ {
 		// 检查上下文是否已取消
@@ -27,22 +39,17 @@ func ScanBlocks(ctx context.Context, client *ethclient.Client, start int64, end
 		}
 
 		// 获取区块头
-		// 使用重试机制增强健壮性
-		var header *types.Header
-		var err error
-		maxRetries := 3
-
-		for retry := 0; retry < maxRetries; retry++ {
-			header, err = client.HeaderByNumber(ctx, big.NewInt(i))
-			if err == nil {
-				break
-			}
-			log.Printf("获取区块 %d 失败 (尝试 %d/%d): %v", i, retry+1, maxRetries, err)
-			time.Sleep(1 * time.Second)
-		}
-
+		header, err := retryValue(ctx, opts.Retry.RetryNotFound(), "获取区块", func() (*types.Header, error) {
+			return client.HeaderByNumber(ctx, big.NewInt(i))
+		}, logBlock(uint64(i)))
 		if err != nil {
-			log.Printf("跳过区块 %d: 无法获取 (%v)", i, err)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if opts.NeverSkip {
+				return fmt.Errorf("获取区块 %d 失败，已停止扫描: %w", i, err)
+			}
+			Logger().Warn("无法获取区块，已跳过", logBlock(uint64(i)), logErr(err))
 			continue
 		}
 
@@ -50,18 +57,22 @@ func ScanBlocks(ctx context.Context, client *ethclient.Client, start int64, end
 		onBlock(header)
 	}
 
-	log.Printf("扫描完成: %d -> %d", start, end)
+	Logger().Info("扫描完成", "start", start, "end", end)
 	return nil
 }
 
 // PrintBlockInfo 打印区块头的基本信息
 // 这是一个公共辅助函数，供 Scanner 和 Subscriber 使用以保持输出格式一致
 func PrintBlockInfo(header *types.Header) {
-	fmt.Println("------------------------------------------------")
-	fmt.Printf("区块高度:   %s\n", header.Number.String())
-	fmt.Printf("区块哈希:   %s\n", header.Hash().Hex())
-	fmt.Printf("父哈希:     %s\n", header.ParentHash.Hex())
-	fmt.Printf("时间戳:     %d\n", header.Time)
-	fmt.Printf("Nonce:      %d\n", header.Nonce.Uint64())
-	fmt.Println("------------------------------------------------")
+	if !IsTextOutput() {
+		emitOrWarn(NewHeaderRecord(header))
+		return
+	}
+	fmt.Fprintln(TextOut(), "------------------------------------------------")
+	fmt.Fprintf(TextOut(), "区块高度:   %s\n", header.Number.String())
+	fmt.Fprintf(TextOut(), "区块哈希:   %s\n", header.Hash().Hex())
+	fmt.Fprintf(TextOut(), "父哈希:     %s\n", header.ParentHash.Hex())
+	fmt.Fprintf(TextOut(), "时间戳:     %d\n", header.Time)
+	fmt.Fprintf(TextOut(), "Nonce:      %d\n", header.Nonce.Uint64())
+	fmt.Fprintln(TextOut(), "------------------------------------------------")
 }
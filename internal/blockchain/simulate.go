@@ -0,0 +1,275 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"sun-DappBackend-homework/internal/contract"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// pendingBlock 用于在 pending 状态上执行 eth_call / eth_estimateGas
+var pendingBlock = big.NewInt(int64(rpc.PendingBlockNumber))
+
+// SimulationResult 写操作在 pending 状态上的模拟结果
+type SimulationResult struct {
+	From       common.Address
+	To         *common.Address // nil 表示合约创建
+	Value      *big.Int
+	ReturnData []byte
+	Gas        uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	MaxCost    *big.Int // Gas * GasFeeCap + Value
+	Balance    *big.Int
+	Err        error // 执行失败 (revert 等) 时的错误
+}
+
+// SimulateTx 在 pending 状态上执行 eth_call 和 eth_estimateGas，不签名也不广播。
+// 调用被 revert 时不会返回错误，而是将解码后的 *ContractError 记录在 SimulationResult.Err 中；
+// contractABI 用于解码自定义错误，可以为 nil。
+func SimulateTx(ctx context.Context, client Client, msg ethereum.CallMsg, contractABI *abi.ABI) (*SimulationResult, error) {
+	result := &SimulationResult{
+		From:  msg.From,
+		To:    msg.To,
+		Value: msg.Value,
+	}
+	if result.Value == nil {
+		result.Value = big.NewInt(0)
+	}
+
+	balance, err := client.PendingBalanceAt(ctx, msg.From)
+	if err != nil {
+		return nil, fmt.Errorf("获取账户余额失败: %v", err)
+	}
+	result.Balance = balance
+
+	result.ReturnData, err = client.CallContract(ctx, msg, pendingBlock)
+	if err != nil {
+		result.Err = DecodeContractError(err, contractABI)
+		return result, nil
+	}
+
+	result.Gas, err = estimateGasAtBlock(ctx, client, msg, pendingBlock)
+	if err != nil {
+		result.Err = DecodeContractError(err, contractABI)
+		return result, nil
+	}
+
+	result.GasTipCap, result.GasFeeCap, err = suggestDynamicFees(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	result.MaxCost = new(big.Int).Mul(new(big.Int).SetUint64(result.Gas), result.GasFeeCap)
+	result.MaxCost.Add(result.MaxCost, result.Value)
+	return result, nil
+}
+
+// SimulationRecord 模拟结果的结构化输出，金额与费用均为 wei 的十进制字符串。
+// ReturnValues 仅在已知方法定义时填充，CSV 中不输出。
+type SimulationRecord struct {
+	From         string         `json:"from"`
+	To           string         `json:"to,omitempty"` // 空表示合约创建
+	Value        string         `json:"value"`
+	Success      bool           `json:"success"`
+	Error        string         `json:"error,omitempty"`
+	ReturnData   string         `json:"returnData,omitempty"`
+	ReturnValues []MethodOutput `json:"returnValues,omitempty"`
+	Gas          uint64         `json:"gas,omitempty"`
+	GasTipCap    string         `json:"gasTipCap,omitempty"`
+	GasFeeCap    string         `json:"gasFeeCap,omitempty"`
+	MaxCost      string         `json:"maxCost,omitempty"`
+	Balance      string         `json:"balance"`
+}
+
+// NewSimulationRecord 由模拟结果构造输出记录；method 不为 nil 时按其输出类型解码返回值
+func NewSimulationRecord(result *SimulationResult, method *abi.Method) *SimulationRecord {
+	r := &SimulationRecord{
+		From:      result.From.Hex(),
+		Value:     result.Value.String(),
+		Success:   result.Err == nil,
+		Gas:       result.Gas,
+		GasTipCap: bigString(result.GasTipCap),
+		GasFeeCap: bigString(result.GasFeeCap),
+		MaxCost:   bigString(result.MaxCost),
+		Balance:   bigString(result.Balance),
+	}
+	if result.To != nil {
+		r.To = result.To.Hex()
+	}
+	if result.Err != nil {
+		r.Error = result.Err.Error()
+		return r
+	}
+	if len(result.ReturnData) > 0 {
+		r.ReturnData = hexutil.Encode(result.ReturnData)
+	}
+	if method != nil && len(method.Outputs) > 0 {
+		if values, err := method.Outputs.Unpack(result.ReturnData); err == nil {
+			r.ReturnValues = NewMethodCallRecord(method, values).Outputs
+		}
+	}
+	return r
+}
+
+func (r *SimulationRecord) CSVHeader() []string {
+	return []string{"from", "to", "value", "success", "error", "return_data", "gas", "gas_tip_cap", "gas_fee_cap", "max_cost", "balance"}
+}
+
+func (r *SimulationRecord) CSVRow() []string {
+	return []string{r.From, r.To, r.Value, strconv.FormatBool(r.Success), r.Error, r.ReturnData, u64(r.Gas),
+		r.GasTipCap, r.GasFeeCap, r.MaxCost, r.Balance}
+}
+
+// PrintSimulation 打印模拟结果；method 不为 nil 时按其输出类型解码返回值。非 text 格式时输出 SimulationRecord
+func PrintSimulation(result *SimulationResult, method *abi.Method) {
+	if !IsTextOutput() {
+		emitOrWarn(NewSimulationRecord(result, method))
+		return
+	}
+	fmt.Fprintln(TextOut(), "================ Dry-run 模拟结果 ================")
+	fmt.Fprintf(TextOut(), "发送方:     %s\n", result.From.Hex())
+	if result.To != nil {
+		fmt.Fprintf(TextOut(), "接收方:     %s\n", result.To.Hex())
+	} else {
+		fmt.Fprintln(TextOut(), "接收方:     (合约创建)")
+	}
+	fmt.Fprintf(TextOut(), "金额:       %s ETH\n", FormatEther(result.Value))
+
+	if result.Err != nil {
+		fmt.Fprintln(TextOut(), "执行结果:   失败")
+		fmt.Fprintf(TextOut(), "失败原因:   %s\n", result.Err)
+		fmt.Fprintln(TextOut(), "==================================================")
+		return
+	}
+
+	fmt.Fprintln(TextOut(), "执行结果:   成功")
+	switch {
+	case method != nil && len(method.Outputs) == 0:
+		fmt.Fprintln(TextOut(), "返回值:     (无)")
+	case method != nil:
+		values, err := method.Outputs.Unpack(result.ReturnData)
+		if err != nil {
+			fmt.Fprintf(TextOut(), "返回值:     %s (解码失败: %v)\n", hexutil.Encode(result.ReturnData), err)
+		} else {
+			for i, v := range values {
+				fmt.Fprintf(TextOut(), "返回值[%d]:  %v\n", i, v)
+			}
+		}
+	case result.To == nil:
+		fmt.Fprintf(TextOut(), "运行时代码: %d 字节\n", len(result.ReturnData))
+	case len(result.ReturnData) > 0:
+		fmt.Fprintf(TextOut(), "返回数据:   %s\n", hexutil.Encode(result.ReturnData))
+	}
+
+	fmt.Fprintf(TextOut(), "预估 Gas:   %d\n", result.Gas)
+	fmt.Fprintf(TextOut(), "优先费上限: %s Gwei\n", FormatGwei(result.GasTipCap))
+	fmt.Fprintf(TextOut(), "费用上限:   %s Gwei\n", FormatGwei(result.GasFeeCap))
+	fmt.Fprintf(TextOut(), "最大花费:   %s ETH\n", FormatEther(result.MaxCost))
+	fmt.Fprintf(TextOut(), "账户余额:   %s ETH\n", FormatEther(result.Balance))
+	if result.Balance.Cmp(result.MaxCost) < 0 {
+		fmt.Fprintln(TextOut(), "警告:       账户余额不足以支付最大花费")
+	}
+	fmt.Fprintln(TextOut(), "==================================================")
+	fmt.Fprintln(TextOut(), "Dry-run 模式: 未签名也未广播任何交易")
+}
+
+// SimulateSendTransaction 模拟一笔 ETH 转账，value 单位为 Wei
+func SimulateSendTransaction(client Client, privateKeyHex string, toAddressHex string, value *big.Int) error {
+	_, fromAddress, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return err
+	}
+	if !common.IsHexAddress(toAddressHex) {
+		return fmt.Errorf("无效的接收方地址: %s", toAddressHex)
+	}
+	toAddress := common.HexToAddress(toAddressHex)
+
+	result, err := SimulateTx(context.Background(), client, ethereum.CallMsg{
+		From:  fromAddress,
+		To:    &toAddress,
+		Value: value,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	PrintSimulation(result, nil)
+	return simulationError(result)
+}
+
+// SimulateDeployContract 模拟部署 Counter 合约，并预测部署后的合约地址
+func SimulateDeployContract(client Client, privateKeyHex string) error {
+	_, fromAddress, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := contract.ContractMetaData.GetAbi()
+	if err != nil {
+		return fmt.Errorf("解析合约 ABI 失败: %v", err)
+	}
+	result, err := SimulateTx(context.Background(), client, ethereum.CallMsg{
+		From: fromAddress,
+		Data: common.FromHex(contract.ContractBin),
+	}, parsed)
+	if err != nil {
+		return err
+	}
+	PrintSimulation(result, nil)
+	if result.Err != nil {
+		return simulationError(result)
+	}
+
+	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	if err != nil {
+		return fmt.Errorf("获取 nonce 失败: %v", err)
+	}
+	fmt.Fprintf(TextOut(), "预测合约地址: %s (nonce %d)\n", crypto.CreateAddress(fromAddress, nonce).Hex(), nonce)
+	return nil
+}
+
+// SimulateIncrementCounter 模拟调用 Counter 合约的 increment 函数
+func SimulateIncrementCounter(client Client, privateKeyHex string, contractAddressHex string) error {
+	_, fromAddress, err := loadPrivateKey(privateKeyHex)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := contract.ContractMetaData.GetAbi()
+	if err != nil {
+		return fmt.Errorf("解析合约 ABI 失败: %v", err)
+	}
+	method := parsed.Methods["increment"]
+	data, err := parsed.Pack("increment")
+	if err != nil {
+		return fmt.Errorf("编码调用数据失败: %v", err)
+	}
+
+	contractAddress := common.HexToAddress(contractAddressHex)
+	result, err := SimulateTx(context.Background(), client, ethereum.CallMsg{
+		From: fromAddress,
+		To:   &contractAddress,
+		Data: data,
+	}, parsed)
+	if err != nil {
+		return err
+	}
+	PrintSimulation(result, &method)
+	return simulationError(result)
+}
+
+// simulationError 模拟执行失败时返回错误，便于脚本根据退出码判断
+func simulationError(result *SimulationResult) error {
+	if result.Err != nil {
+		return fmt.Errorf("模拟执行失败: %w", result.Err)
+	}
+	return nil
+}
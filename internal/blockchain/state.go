@@ -0,0 +1,150 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrHistoricalStateUnavailable 节点已裁剪该区块的状态 (非归档节点) 时返回
+var ErrHistoricalStateUnavailable = errors.New("节点无法提供该区块的历史状态，请使用归档节点 (archive node)")
+
+// historicalStateErrors 各类节点在缺少历史状态时返回的错误信息：
+// geth 的 "missing trie node"、"historical state <root> is not available"、"required historical state unavailable"、
+// "state histories haven't been fully indexed"，Nethermind / Besu 的 "state is not available" / "state not available"，
+// 以及 Infura 的 "does not have access to archive state"。只匹配完整短语，避免误判其他包含 pruned 等字样的错误。
+var historicalStateErrors = regexp.MustCompile(`missing trie node` +
+	`|historical state (0x)?[0-9a-f]* ?(is not available|unavailable)` +
+	`|state histories haven't been fully indexed` +
+	`|state (is )?not available` +
+	`|access to archive state`)
+
+// BlockRef 指定读取状态时使用的区块：区块号、区块哈希或标签。
+// 零值表示 latest。
+type BlockRef struct {
+	Number *big.Int    // nil 表示 latest；负数对应 rpc 标签 (safe / finalized / pending / earliest)
+	Hash   common.Hash // 不为零时按区块哈希读取
+}
+
+// ParseBlockRef 解析 -block 参数。
+// 支持十进制区块号、0x 开头的十六进制区块号、32 字节区块哈希，
+// 以及 latest / safe / finalized / pending / earliest 标签；空字符串表示 latest。
+func ParseBlockRef(s string) (BlockRef, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "", "latest":
+		return BlockRef{}, nil
+	case "safe":
+		return BlockRef{Number: big.NewInt(int64(rpc.SafeBlockNumber))}, nil
+	case "finalized":
+		return BlockRef{Number: big.NewInt(int64(rpc.FinalizedBlockNumber))}, nil
+	case "pending":
+		return BlockRef{Number: big.NewInt(int64(rpc.PendingBlockNumber))}, nil
+	case "earliest":
+		return BlockRef{Number: big.NewInt(0)}, nil
+	}
+
+	if strings.HasPrefix(s, "0x") && len(s) == 2+2*common.HashLength {
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return BlockRef{}, fmt.Errorf("无效的区块哈希: %s", s)
+		}
+		return BlockRef{Hash: common.BytesToHash(b)}, nil
+	}
+
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 {
+		return BlockRef{}, fmt.Errorf("无效的区块参数: %q (应为区块号、区块哈希或 latest/safe/finalized)", s)
+	}
+	return BlockRef{Number: n}, nil
+}
+
+// IsLatest 是否为默认的 latest
+func (r BlockRef) IsLatest() bool {
+	return r.Number == nil && r.Hash == (common.Hash{})
+}
+
+// IsHash 是否按区块哈希读取
+func (r BlockRef) IsHash() bool {
+	return r.Hash != (common.Hash{})
+}
+
+func (r BlockRef) String() string {
+	switch {
+	case r.IsHash():
+		return r.Hash.Hex()
+	case r.Number == nil:
+		return "latest"
+	case r.Number.Sign() >= 0:
+		return r.Number.String()
+	default:
+		return rpc.BlockNumber(r.Number.Int64()).String()
+	}
+}
+
+// CallOpts 返回在该区块执行 eth_call 的 bind.CallOpts
+func (r BlockRef) CallOpts(ctx context.Context) *bind.CallOpts {
+	opts := &bind.CallOpts{Context: ctx}
+	switch {
+	case r.IsHash():
+		opts.BlockHash = r.Hash
+	case r.Number != nil && r.Number.Int64() == int64(rpc.PendingBlockNumber):
+		opts.Pending = true
+	default:
+		opts.BlockNumber = r.Number
+	}
+	return opts
+}
+
+// ResolveBlockNumber 将区块引用解析为具体的区块号 (标签和哈希会查询对应区块头)
+func ResolveBlockNumber(ctx context.Context, client Client, r BlockRef) (*big.Int, error) {
+	if r.IsHash() {
+		header, err := client.HeaderByHash(ctx, r.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("获取区块 %s 失败: %v", r.Hash.Hex(), err)
+		}
+		return header.Number, nil
+	}
+	if r.Number != nil && r.Number.Sign() >= 0 {
+		return r.Number, nil
+	}
+	header, err := client.HeaderByNumber(ctx, r.Number)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 区块失败: %v", r, err)
+	}
+	return header.Number, nil
+}
+
+// GetBalanceAt 读取账户在指定区块的 ETH 余额
+func GetBalanceAt(ctx context.Context, client Client, account common.Address, r BlockRef) (*big.Int, error) {
+	var balance *big.Int
+	var err error
+	if r.IsHash() {
+		balance, err = balanceAtHash(ctx, client, account, r.Hash)
+	} else {
+		balance, err = client.BalanceAt(ctx, account, r.Number)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 余额失败: %w", account.Hex(), wrapStateError(err, r))
+	}
+	return balance, nil
+}
+
+// wrapStateError 将节点缺少历史状态的错误转换为 ErrHistoricalStateUnavailable
+func wrapStateError(err error, r BlockRef) error {
+	if err == nil || r.IsLatest() {
+		return err
+	}
+	if historicalStateErrors.MatchString(strings.ToLower(err.Error())) {
+		return fmt.Errorf("%w (区块 %s): %v", ErrHistoricalStateUnavailable, r, err)
+	}
+	return err
+}
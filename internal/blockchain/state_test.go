@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestParseBlockRef(t *testing.T) {
+	hash := "0x" + strings.Repeat("ab", 32)
+	tests := []struct {
+		input   string
+		number  *big.Int
+		hash    common.Hash
+		str     string
+		wantErr bool
+	}{
+		{input: "", str: "latest"},
+		{input: "latest", str: "latest"},
+		{input: " Latest ", str: "latest"},
+		{input: "safe", number: big.NewInt(int64(rpc.SafeBlockNumber)), str: "safe"},
+		{input: "finalized", number: big.NewInt(int64(rpc.FinalizedBlockNumber)), str: "finalized"},
+		{input: "FINALIZED", number: big.NewInt(int64(rpc.FinalizedBlockNumber)), str: "finalized"},
+		{input: "pending", number: big.NewInt(int64(rpc.PendingBlockNumber)), str: "pending"},
+		{input: "earliest", number: big.NewInt(0), str: "0"},
+		{input: "5432100", number: big.NewInt(5432100), str: "5432100"},
+		{input: "0x52e3e4", number: big.NewInt(0x52e3e4), str: "5432292"},
+		{input: "0", number: big.NewInt(0), str: "0"},
+		{input: hash, hash: common.HexToHash(hash), str: hash},
+		{input: "-1", wantErr: true},
+		{input: "1.5", wantErr: true},
+		{input: "newest", wantErr: true},
+		{input: "0x" + strings.Repeat("zz", 32), wantErr: true},
+		{input: "0xzz", wantErr: true},
+	}
+	for _, tt := range tests {
+		ref, err := ParseBlockRef(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseBlockRef(%q) = %v, 期望返回错误", tt.input, ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBlockRef(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		if (ref.Number == nil) != (tt.number == nil) || (ref.Number != nil && ref.Number.Cmp(tt.number) != 0) {
+			t.Errorf("ParseBlockRef(%q).Number = %v, 期望 %v", tt.input, ref.Number, tt.number)
+		}
+		if ref.Hash != tt.hash {
+			t.Errorf("ParseBlockRef(%q).Hash = %s, 期望 %s", tt.input, ref.Hash.Hex(), tt.hash.Hex())
+		}
+		if ref.String() != tt.str {
+			t.Errorf("ParseBlockRef(%q).String() = %s, 期望 %s", tt.input, ref, tt.str)
+		}
+		if ref.IsLatest() != (tt.str == "latest") {
+			t.Errorf("ParseBlockRef(%q).IsLatest() = %v", tt.input, ref.IsLatest())
+		}
+	}
+}
+
+func TestBlockRefCallOpts(t *testing.T) {
+	pending, _ := ParseBlockRef("pending")
+	if opts := pending.CallOpts(context.Background()); !opts.Pending || opts.BlockNumber != nil {
+		t.Errorf("pending 应设置 Pending，得到 %+v", opts)
+	}
+	byHash, _ := ParseBlockRef("0x" + strings.Repeat("01", 32))
+	if opts := byHash.CallOpts(context.Background()); opts.BlockHash != byHash.Hash || opts.BlockNumber != nil {
+		t.Errorf("区块哈希应设置 BlockHash，得到 %+v", opts)
+	}
+	finalized, _ := ParseBlockRef("finalized")
+	if opts := finalized.CallOpts(context.Background()); opts.BlockNumber.Int64() != int64(rpc.FinalizedBlockNumber) {
+		t.Errorf("finalized 应设置对应的 BlockNumber，得到 %+v", opts)
+	}
+}
+
+func TestWrapStateError(t *testing.T) {
+	historical := BlockRef{Number: big.NewInt(100)}
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{msg: "missing trie node 5b1f0a (path ) state 0x5b1f0a is not available", want: true},
+		{msg: "historical state 0x3c1e2d4f is not available", want: true},
+		{msg: "required historical state unavailable (reexec=128)", want: true},
+		{msg: "state histories haven't been fully indexed yet", want: true},
+		{msg: "Requested state is not available", want: true},
+		{msg: "project ID does not have access to archive state", want: true},
+		{msg: "execution reverted: archive contract paused", want: false},
+		{msg: "receipt for pruned transaction lookup failed", want: false},
+		{msg: "connection refused", want: false},
+	}
+	for _, tt := range tests {
+		err := wrapStateError(errors.New(tt.msg), historical)
+		if got := errors.Is(err, ErrHistoricalStateUnavailable); got != tt.want {
+			t.Errorf("wrapStateError(%q) 识别为历史状态缺失 = %v, 期望 %v", tt.msg, got, tt.want)
+		}
+	}
+
+	// latest 查询的错误与历史状态无关，原样返回
+	latestErr := errors.New("missing trie node")
+	if err := wrapStateError(latestErr, BlockRef{}); err != latestErr {
+		t.Errorf("latest 的错误应原样返回，得到 %v", err)
+	}
+}
@@ -2,7 +2,7 @@ package blockchain
 
 import (
 	"context"
-	"log"
+	"errors"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
@@ -10,8 +10,16 @@ import (
 )
 
 // SubscribeNewHead 订阅新区块头并打印其信息。
-// 它处理断线重连、优雅退出以及启动时的回放扫描。
-func SubscribeNewHead(ctx context.Context, wsURL string, startBlock int64) {
+// 它处理断线重连、优雅退出以及启动时的回放扫描。chainID 不为 0 时校验节点的链 ID，limiter 为 nil 时不限流。
+func SubscribeNewHead(ctx context.Context, wsURL string, chainID uint64, startBlock int64, limiter *RateLimiter) {
+	WatchNewHeads(ctx, wsURL, chainID, startBlock, limiter, func(_ Client, h *types.Header) {
+		PrintBlockInfo(h)
+	})
+}
+
+// WatchNewHeads 订阅新区块头，并对每个区块 (包括回放扫描补齐的区块) 按顺序调用 onHeader。
+// onHeader 收到当前的 WebSocket 客户端 (已按 limiter 限流)，可用于进一步查询；断线重连、回放逻辑与 SubscribeNewHead 相同。
+func WatchNewHeads(ctx context.Context, wsURL string, chainID uint64, startBlock int64, limiter *RateLimiter, onHeader func(Client, *types.Header)) {
 	var client *ethclient.Client
 	var sub interface {
 		Err() <-chan error
@@ -28,88 +36,108 @@ func SubscribeNewHead(ctx context.Context, wsURL string, startBlock int64) {
 		lastProcessedBlock = startBlock - 1
 	}
 
-	// 初始化连接
-	client, err = ethclient.DialContext(ctx, wsURL)
-	if err != nil {
-		log.Printf("连接 WebSocket 失败: %v。5秒后重试...", err)
-	} else {
-		log.Println("已连接到 WebSocket")
+	// 连续重连次数，按 ReconnectPolicy 指数退避，订阅成功后清零
+	reconnects := 0
+	// reset 关闭当前连接并等待退避时间，ctx 取消时返回 false
+	reset := func() bool {
+		if sub != nil {
+			sub.Unsubscribe()
+			sub = nil
+		}
+		if client != nil {
+			client.Close()
+			client = nil
+		}
+		delay := ReconnectPolicy.Backoff(reconnects)
+		reconnects++
+		Logger().Info("稍后重新连接", "delay", delay.Round(time.Millisecond), logAttempt(reconnects))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+			return true
+		}
 	}
 
 	for {
 		// 1. 确保客户端已连接
 		if client == nil {
-			select {
-			case <-ctx.Done():
+			client, err = dialWebSocket(ctx, wsURL, chainID)
+			if errors.Is(err, ErrChainIDMismatch) {
+				Logger().Error("WebSocket 节点不是期望的网络，停止订阅", logErr(err))
 				return
-			case <-time.After(5 * time.Second):
-				log.Println("正在重新连接 WebSocket...")
-				client, err = ethclient.DialContext(ctx, wsURL)
-				if err != nil {
-					log.Printf("连接失败: %v。正在重试...", err)
-					client = nil
-					continue
+			}
+			if err != nil {
+				Logger().Warn("连接 WebSocket 失败", logErr(err))
+				client = nil
+				if !reset() {
+					return
 				}
-				log.Println("已连接到 WebSocket")
+				continue
 			}
+			Logger().Debug("已连接到 WebSocket")
 		}
 
 		// 2. 如果尚未订阅，则进行订阅流程
 		if sub == nil {
 			// 在订阅前，先检查是否需要补数据 (Scanner)
 			// 获取当前网络最新区块
-			header, err := client.HeaderByNumber(ctx, nil)
+			limited := limitClient(client, limiter)
+			header, err := limited.HeaderByNumber(ctx, nil)
 			if err != nil {
-				log.Printf("获取最新区块头失败: %v。重置连接...", err)
-				client.Close()
-				client = nil
-				time.Sleep(2 * time.Second)
+				Logger().Warn("获取最新区块头失败，重置连接", logErr(err))
+				if !reset() {
+					return
+				}
 				continue
 			}
 			latestBlock := header.Number.Int64()
 
 			// 如果有上一次处理的区块记录，且小于最新区块，则进行补漏扫描
 			if lastProcessedBlock >= 0 && lastProcessedBlock < latestBlock {
-				log.Printf("检测到区块缺口: 本地(%d) -> 网络(%d)。开始补数据...", lastProcessedBlock, latestBlock)
-				err := ScanBlocks(ctx, client, lastProcessedBlock+1, latestBlock, func(h *types.Header) {
-					PrintBlockInfo(h)
+				Logger().Info("检测到区块缺口，开始补数据", "from", lastProcessedBlock+1, "to", latestBlock)
+				// NeverSkip: 补数据失败时重连后从失败的区块继续，不丢弃区块
+				err := ScanBlocksWith(ctx, limited, lastProcessedBlock+1, latestBlock, ScanOptions{Retry: DefaultRetryPolicy, NeverSkip: true}, func(h *types.Header) {
+					onHeader(limited, h)
 					lastProcessedBlock = h.Number.Int64()
 				})
 				if err != nil {
-					log.Printf("扫描补数据过程中出错: %v。将尝试继续订阅...", err)
+					if ctx.Err() != nil {
+						return
+					}
+					Logger().Warn("扫描补数据过程中出错，重置连接", logErr(err))
+					if !reset() {
+						return
+					}
+					continue
 				}
 			} else {
 				// 如果没有 lastProcessedBlock (首次运行且未指定 startBlock)，则将 latestBlock 视为起始点
 				if lastProcessedBlock == -1 {
 					lastProcessedBlock = latestBlock
-					log.Printf("未指定起始区块，将从最新区块 %d 开始监听", latestBlock)
+					Logger().Info("未指定起始区块，将从最新区块开始监听", logBlock(uint64(latestBlock)))
 				}
 			}
 
 			// 创建新通道并订阅
 			headers = make(chan *types.Header)
-			sub, err = client.SubscribeNewHead(ctx, headers)
+			sub, err = limited.SubscribeNewHead(ctx, headers)
 			if err != nil {
-				log.Printf("订阅失败: %v。5秒后重试...", err)
-				client.Close()
-				client = nil
+				Logger().Warn("订阅失败", logErr(err))
 				sub = nil
-
-				select {
-				case <-ctx.Done():
+				if !reset() {
 					return
-				case <-time.After(5 * time.Second):
-					continue
 				}
-			} else {
-				log.Println("已订阅新区块头")
+				continue
 			}
+			Logger().Info("已订阅新区块头")
+			reconnects = 0
 		}
 
 		// 3. 处理事件循环
 		select {
 		case <-ctx.Done():
-			log.Println("上下文已取消，正在取消订阅...")
+			Logger().Info("上下文已取消，正在取消订阅")
 			if sub != nil {
 				sub.Unsubscribe()
 			}
@@ -119,20 +147,18 @@ func SubscribeNewHead(ctx context.Context, wsURL string, startBlock int64) {
 			return
 
 		case err := <-sub.Err():
-			log.Printf("订阅错误: %v。正在重新连接...", err)
-			sub.Unsubscribe()
-			sub = nil
-			client.Close()
-			client = nil
-			// 重连前等待，避免紧密循环
-			time.Sleep(2 * time.Second)
+			Logger().Warn("订阅错误，正在重新连接", logErr(err))
+			// 重连前按退避策略等待，避免紧密循环
+			if !reset() {
+				return
+			}
 
 		case header, ok := <-headers:
 			if !ok {
-				log.Println("Header 通道已关闭。正在重新连接...")
-				sub = nil
-				client = nil
-				time.Sleep(1 * time.Second)
+				Logger().Warn("Header 通道已关闭，正在重新连接")
+				if !reset() {
+					return
+				}
 				continue
 			}
 
@@ -148,7 +174,7 @@ func SubscribeNewHead(ctx context.Context, wsURL string, startBlock int64) {
 			// 如果我们错过了中间的，通常是因为断线（上面会处理）。
 			// 如果是网络延迟导致的乱序或跳跃，这里简单记录。
 			if currentNum > lastProcessedBlock+1 {
-				log.Printf("警告: 收到非连续区块 (上一个: %d, 当前: %d)。可能丢失了部分区块。", lastProcessedBlock, currentNum)
+				Logger().Warn("收到非连续区块，可能丢失了部分区块", "previous", lastProcessedBlock, logBlock(uint64(currentNum)))
 				// 可选：在这里也可以触发一次小范围 ScanBlocks
 				// err := ScanBlocks(ctx, client, lastProcessedBlock+1, currentNum-1, func(h *types.Header) {
 				// 	PrintBlockInfo(h)
@@ -156,7 +182,7 @@ func SubscribeNewHead(ctx context.Context, wsURL string, startBlock int64) {
 				// })
 			}
 
-			PrintBlockInfo(header)
+			onHeader(limitClient(client, limiter), header)
 			lastProcessedBlock = currentNum
 		}
 	}
@@ -2,8 +2,8 @@ package blockchain
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -13,8 +13,8 @@ import (
 )
 
 // SubscribeFilterLogs 订阅合约日志事件并打印
-// 支持断线重连和优雅退出
-func SubscribeFilterLogs(ctx context.Context, wsURL string, contractAddr string) {
+// 支持断线重连和优雅退出，chainID 不为 0 时校验节点的链 ID，limiter 为 nil 时不限流
+func SubscribeFilterLogs(ctx context.Context, wsURL string, chainID uint64, contractAddr string, limiter *RateLimiter) {
 	var client *ethclient.Client
 	var sub interface {
 		Err() <-chan error
@@ -27,12 +27,13 @@ func SubscribeFilterLogs(ctx context.Context, wsURL string, contractAddr string)
 	var addresses []common.Address
 	if contractAddr != "" {
 		if !common.IsHexAddress(contractAddr) {
-			log.Fatalf("无效的合约地址: %s", contractAddr)
+			Logger().Error("无效的合约地址", "contract", contractAddr)
+			return
 		}
 		addresses = []common.Address{common.HexToAddress(contractAddr)}
-		log.Printf("正在监听合约地址: %s", contractAddr)
+		Logger().Info("正在监听合约地址", "contract", contractAddr)
 	} else {
-		log.Println("未指定合约地址，将监听所有事件 (注意：流量可能很大)")
+		Logger().Warn("未指定合约地址，将监听所有事件 (注意：流量可能很大)")
 	}
 
 	// 构建过滤条件
@@ -42,56 +43,68 @@ func SubscribeFilterLogs(ctx context.Context, wsURL string, contractAddr string)
 		Addresses: addresses,
 	}
 
-	// 初始化连接
-	client, err = ethclient.DialContext(ctx, wsURL)
-	if err != nil {
-		log.Printf("连接 WebSocket 失败: %v。5秒后重试...", err)
-	} else {
-		log.Println("已连接到 WebSocket (Log Subscription)")
+	// 连续重连次数，按 ReconnectPolicy 指数退避，订阅成功后清零
+	reconnects := 0
+	// reset 关闭当前连接并等待退避时间，ctx 取消时返回 false
+	reset := func() bool {
+		if sub != nil {
+			sub.Unsubscribe()
+			sub = nil
+		}
+		if client != nil {
+			client.Close()
+			client = nil
+		}
+		delay := ReconnectPolicy.Backoff(reconnects)
+		reconnects++
+		Logger().Info("稍后重新连接", "delay", delay.Round(time.Millisecond), logAttempt(reconnects))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+			return true
+		}
 	}
 
 	for {
 		// 1. 确保客户端已连接
 		if client == nil {
-			select {
-			case <-ctx.Done():
+			client, err = dialWebSocket(ctx, wsURL, chainID)
+			if errors.Is(err, ErrChainIDMismatch) {
+				Logger().Error("WebSocket 节点不是期望的网络，停止订阅", logErr(err))
 				return
-			case <-time.After(5 * time.Second):
-				log.Println("正在重新连接 WebSocket...")
-				client, err = ethclient.DialContext(ctx, wsURL)
-				if err != nil {
-					log.Printf("连接失败: %v。正在重试...", err)
-					client = nil
-					continue
+			}
+			if err != nil {
+				Logger().Warn("连接 WebSocket 失败", logErr(err))
+				client = nil
+				if !reset() {
+					return
 				}
-				log.Println("已连接到 WebSocket")
+				continue
 			}
+			Logger().Debug("已连接到 WebSocket (日志订阅)")
 		}
 
 		// 2. 如果尚未订阅，则进行订阅
 		if sub == nil {
 			logs = make(chan types.Log)
-			sub, err = client.SubscribeFilterLogs(ctx, query, logs)
+			sub, err = limitClient(client, limiter).SubscribeFilterLogs(ctx, query, logs)
 			if err != nil {
-				log.Printf("订阅日志失败: %v。5秒后重试...", err)
-				client.Close()
-				client = nil
+				Logger().Warn("订阅日志失败", logErr(err))
 				sub = nil
-				select {
-				case <-ctx.Done():
+				if !reset() {
 					return
-				case <-time.After(5 * time.Second):
-					continue
 				}
-			} else {
-				log.Println("已成功订阅日志事件")
+				continue
 			}
+			Logger().Info("已成功订阅日志事件")
+			reconnects = 0
 		}
 
 		// 3. 处理事件循环
 		select {
 		case <-ctx.Done():
-			log.Println("上下文已取消，正在取消日志订阅...")
+			Logger().Info("上下文已取消，正在取消日志订阅")
 			if sub != nil {
 				sub.Unsubscribe()
 			}
@@ -101,12 +114,10 @@ func SubscribeFilterLogs(ctx context.Context, wsURL string, contractAddr string)
 			return
 
 		case err := <-sub.Err():
-			log.Printf("订阅错误: %v。正在重新连接...", err)
-			sub.Unsubscribe()
-			sub = nil
-			client.Close()
-			client = nil
-			time.Sleep(2 * time.Second)
+			Logger().Warn("订阅错误，正在重新连接", logErr(err))
+			if !reset() {
+				return
+			}
 
 		case vLog := <-logs:
 			printLogInfo(vLog)
@@ -116,17 +127,21 @@ func SubscribeFilterLogs(ctx context.Context, wsURL string, contractAddr string)
 
 // printLogInfo 打印日志详细信息
 func printLogInfo(vLog types.Log) {
-	fmt.Println("================================================")
-	fmt.Printf("区块号:     %d\n", vLog.BlockNumber)
-	fmt.Printf("交易哈希:   %s\n", vLog.TxHash.Hex())
-	fmt.Printf("日志索引:   %d\n", vLog.Index)
-	fmt.Printf("合约地址:   %s\n", vLog.Address.Hex())
-	
-	fmt.Println("Topics:")
+	if !IsTextOutput() {
+		emitOrWarn(NewLogRecord(vLog))
+		return
+	}
+	fmt.Fprintln(TextOut(), "================================================")
+	fmt.Fprintf(TextOut(), "区块号:     %d\n", vLog.BlockNumber)
+	fmt.Fprintf(TextOut(), "交易哈希:   %s\n", vLog.TxHash.Hex())
+	fmt.Fprintf(TextOut(), "日志索引:   %d\n", vLog.Index)
+	fmt.Fprintf(TextOut(), "合约地址:   %s\n", vLog.Address.Hex())
+
+	fmt.Fprintln(TextOut(), "Topics:")
 	for i, topic := range vLog.Topics {
-		fmt.Printf("  [%d] %s\n", i, topic.Hex())
+		fmt.Fprintf(TextOut(), "  [%d] %s\n", i, topic.Hex())
 	}
 
-	fmt.Printf("Data (Hex): %x\n", vLog.Data)
-	fmt.Println("================================================")
+	fmt.Fprintf(TextOut(), "Data (Hex): %x\n", vLog.Data)
+	fmt.Fprintln(TextOut(), "================================================")
 }
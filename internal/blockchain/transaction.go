@@ -2,99 +2,66 @@ package blockchain
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"log"
+	"fmt"
 	"math/big"
-	"strings"
-
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// SendTransaction 从与私钥关联的账户发送交易
-func SendTransaction(client *ethclient.Client, privateKeyHex string, toAddressHex string, amount float64) {
+// SendTransaction 从与私钥关联的账户发送交易，value 单位为 Wei
+// 内部依次执行构建 (BuildUnsignedTx)、签名 (SignUnsignedTx) 和广播三个步骤，
+// 与冷钱包离线签名流程共用同一套逻辑。
+func SendTransaction(client Client, privateKeyHex string, toAddressHex string, value *big.Int) error {
+	ctx := context.Background()
+
 	// 1. 加载私钥
-	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	_, fromAddress, err := loadPrivateKey(privateKeyHex)
 	if err != nil {
-		log.Fatalf("解析私钥失败: %v", err)
+		return fmt.Errorf("解析私钥失败: %v", err)
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatal("无法断言类型: 公钥不是 *ecdsa.PublicKey 类型")
+	// 2. 构建未签名交易 (Nonce、EIP-1559 动态费用、链 ID)，节点的链 ID 须与客户端附带的网络设置一致
+	unsigned, err := BuildUnsignedTx(ctx, client, fromAddress.Hex(), toAddressHex, value, NetworkOf(client).ChainID)
+	if err != nil {
+		return fmt.Errorf("构建交易失败: %w", err)
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-
-	// 2. 获取 Nonce
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	// 3. 签名交易 (使用构建时从节点获取的链 ID)
+	chainID, ok := new(big.Int).SetString(unsigned.ChainID, 10)
+	if !ok || chainID.Sign() <= 0 || !chainID.IsUint64() {
+		return fmt.Errorf("签名交易失败: 无效的链 ID %q", unsigned.ChainID)
+	}
+	signedTx, err := SignUnsignedTx(unsigned, privateKeyHex, chainID.Uint64())
 	if err != nil {
-		log.Fatalf("获取 nonce 失败: %v", err)
+		return fmt.Errorf("签名交易失败: %w", err)
 	}
 
-	// 3. 设置金额 (单位: Wei)
-	value := big.NewInt(0)
-	// 1 ETH = 10^18 Wei. 我们使用 big.Float 转换助手或简单的乘法（如果金额是整数）。
-	// 为简单起见，我们假设金额单位是 ETH 并转换为 Wei。
-	// 1 ETH = 1000000000000000000 Wei
-	amountWei := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(1e18))
-	amountWei.Int(value) // 将 big.Float 转换为 big.Int
+	// 4. 发送交易
+	PrintTxSummary(signedTx)
+	if err := sendTransactionWithRetry(ctx, client, signedTx); err != nil {
+		return fmt.Errorf("发送交易失败: %w", err)
+	}
+	PrintTxSent(client, "交易已发送", signedTx)
+	return nil
+}
 
-	// 4. EIP-1559 动态费用
-	gasTipCap, err := client.SuggestGasTipCap(context.Background())
+// suggestDynamicFees 获取 EIP-1559 动态费用建议
+// 返回 GasTipCap 以及 GasFeeCap (BaseFee * 2 + GasTipCap)
+func suggestDynamicFees(ctx context.Context, client Client) (*big.Int, *big.Int, error) {
+	gasTipCap, err := client.SuggestGasTipCap(ctx)
 	if err != nil {
-		log.Fatalf("获取 gas tip cap 建议失败: %v", err)
+		return nil, nil, fmt.Errorf("获取 gas tip cap 建议失败: %v", err)
 	}
 
-	header, err := client.HeaderByNumber(context.Background(), nil)
+	header, err := client.HeaderByNumber(ctx, nil)
 	if err != nil {
-		log.Fatalf("获取最新区块头失败: %v", err)
+		return nil, nil, fmt.Errorf("获取最新区块头失败: %v", err)
+	}
+	if header.BaseFee == nil {
+		return nil, nil, fmt.Errorf("当前网络不支持 EIP-1559 (区块头缺少 BaseFee)")
 	}
 
-	baseFee := header.BaseFee
-	// 计算 GasFeeCap: BaseFee * 2 + GasTipCap
 	gasFeeCap := new(big.Int).Add(
-		new(big.Int).Mul(baseFee, big.NewInt(2)),
+		new(big.Int).Mul(header.BaseFee, big.NewInt(2)),
 		gasTipCap,
 	)
-
-	// 5. Gas 限制
-	gasLimit := uint64(21000) // ETH 转账的标准限制
-
-	toAddress := common.HexToAddress(toAddressHex)
-
-	// 6. 创建交易 (EIP-1559)
-	chainID, err := client.NetworkID(context.Background())
-	if err != nil {
-		log.Fatalf("获取链 ID 失败: %v", err)
-	}
-
-	txData := &types.DynamicFeeTx{
-		ChainID:   chainID,
-		Nonce:     nonce,
-		GasTipCap: gasTipCap,
-		GasFeeCap: gasFeeCap,
-		Gas:       gasLimit,
-		To:        &toAddress,
-		Value:     value,
-		Data:      nil,
-	}
-	tx := types.NewTx(txData)
-
-	// 7. 签名交易
-	signer := types.NewLondonSigner(chainID)
-	signedTx, err := types.SignTx(tx, signer, privateKey)
-	if err != nil {
-		log.Fatalf("签名交易失败: %v", err)
-	}
-
-	// 8. 发送交易
-	err = client.SendTransaction(context.Background(), signedTx)
-	if err != nil {
-		log.Fatalf("发送交易失败: %v", err)
-	}
+	return gasTipCap, gasFeeCap, nil
 }
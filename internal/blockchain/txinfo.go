@@ -0,0 +1,380 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"sun-DappBackend-homework/internal/contract"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 交易状态
+const (
+	TxStatusPending = "pending" // 在交易池中，尚未打包
+	TxStatusSuccess = "success" // 已打包且执行成功
+	TxStatusFailed  = "failed"  // 已打包但执行失败
+)
+
+// DecodedArg 解码后的一个参数
+type DecodedArg struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Value   interface{} `json:"value"`
+	Indexed bool        `json:"indexed,omitempty"`
+}
+
+// DecodedCall 按 ABI 解码的调用数据
+type DecodedCall struct {
+	Method    string       `json:"method"`
+	Signature string       `json:"signature"`
+	Args      []DecodedArg `json:"args"`
+}
+
+// DecodedLog 日志及其按 ABI 解码的事件 (未知事件时 Event 为空)
+type DecodedLog struct {
+	*LogRecord
+	Event     string       `json:"event,omitempty"`
+	Signature string       `json:"signature,omitempty"`
+	Args      []DecodedArg `json:"args,omitempty"`
+}
+
+// TxInfoRecord txinfo 模式的输出：交易、状态、回执以及解码后的输入和日志
+type TxInfoRecord struct {
+	Status        string        `json:"status"`
+	Confirmations uint64        `json:"confirmations"`
+	Timestamp     uint64        `json:"timestamp,omitempty"`
+	Transaction   *TxRecord     `json:"transaction"`
+	Input         *DecodedCall  `json:"input,omitempty"`
+	Logs          []*DecodedLog `json:"logs,omitempty"`
+	RevertReason  string        `json:"revertReason,omitempty"`
+}
+
+func (r *TxInfoRecord) CSVHeader() []string {
+	return append([]string{"status", "confirmations", "timestamp", "method"}, r.Transaction.CSVHeader()...)
+}
+
+func (r *TxInfoRecord) CSVRow() []string {
+	method := ""
+	if r.Input != nil {
+		method = r.Input.Signature
+	}
+	timestamp := ""
+	if r.Timestamp > 0 {
+		timestamp = u64(r.Timestamp)
+	}
+	return append([]string{r.Status, u64(r.Confirmations), timestamp, method}, r.Transaction.CSVRow()...)
+}
+
+// knownABIs 返回解码时依次尝试的 ABI：用户提供的 ABI 优先，其次是内置的 Counter 与 ERC-20
+func knownABIs(extra *abi.ABI) []*abi.ABI {
+	var abis []*abi.ABI
+	if extra != nil {
+		abis = append(abis, extra)
+	}
+	if counterABI, err := contract.ContractMetaData.GetAbi(); err == nil {
+		abis = append(abis, counterABI)
+	}
+	return append(abis, &parsedERC20ABI)
+}
+
+// GetTxInfo 查询交易及其状态。已打包的交易会附带回执、确认数和解码后的日志；
+// 执行失败时在父区块状态上重放以获取 revert 原因。contractABI 可为 nil。
+func GetTxInfo(ctx context.Context, client Client, hash common.Hash, contractABI *abi.ABI) (*TxInfoRecord, error) {
+	tx, isPending, err := client.TransactionByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("未找到交易 %s (可能尚未广播或已被交易池丢弃)", hash.Hex())
+		}
+		return nil, fmt.Errorf("获取交易失败: %v", err)
+	}
+
+	abis := knownABIs(contractABI)
+	info := &TxInfoRecord{
+		Status:      TxStatusPending,
+		Transaction: NewTxRecord(tx, nil),
+		Input:       decodeCallData(tx.Data(), abis),
+	}
+	if isPending {
+		return info, nil
+	}
+
+	receipt, err := client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易回执失败: %v", err)
+	}
+	blockNumber := receipt.BlockNumber.Uint64()
+	index := int(receipt.TransactionIndex)
+	info.Transaction.BlockNumber = &blockNumber
+	info.Transaction.Index = &index
+	info.Transaction.Receipt = NewReceiptRecord(receipt)
+	for _, l := range receipt.Logs {
+		info.Logs = append(info.Logs, decodeLog(*l, abis))
+	}
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新区块头失败: %v", err)
+	}
+	if latest := header.Number.Uint64(); latest >= blockNumber {
+		info.Confirmations = latest - blockNumber + 1
+	}
+	block, err := client.HeaderByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易所在区块失败: %v", err)
+	}
+	info.Timestamp = block.Time
+
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		info.Status = TxStatusSuccess
+		return info, nil
+	}
+	info.Status = TxStatusFailed
+	info.RevertReason = replayRevertReason(ctx, client, tx, info.Transaction.From, receipt.BlockNumber, abis)
+	return info, nil
+}
+
+// replayRevertReason 在交易所在区块的父区块状态上重放交易，返回解码后的 revert 原因。
+// 同一区块中排在前面的交易可能改变状态，因此结果仅供参考；无法获取时返回空字符串。
+func replayRevertReason(ctx context.Context, client Client, tx *types.Transaction, from string, blockNumber *big.Int, abis []*abi.ABI) string {
+	if from == "" || blockNumber.Sign() == 0 {
+		return ""
+	}
+	msg := ethereum.CallMsg{
+		From:  common.HexToAddress(from),
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+	_, err := client.CallContract(ctx, msg, new(big.Int).Sub(blockNumber, big.NewInt(1)))
+	if err == nil {
+		return ""
+	}
+	return DecodeContractError(err, abis...).Error()
+}
+
+// GetReceipt 查询交易回执 (含日志)
+func GetReceipt(ctx context.Context, client Client, hash common.Hash) (*ReceiptRecord, error) {
+	receipt, err := client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("未找到交易 %s 的回执 (交易不存在或尚未打包)", hash.Hex())
+		}
+		return nil, fmt.Errorf("获取交易回执失败: %v", err)
+	}
+	record := NewReceiptRecord(receipt)
+	record.Logs = make([]*LogRecord, len(receipt.Logs))
+	for i, l := range receipt.Logs {
+		record.Logs[i] = NewLogRecord(*l)
+	}
+	return record, nil
+}
+
+// decodeCallData 按 4 字节选择器在已知 ABI 中查找方法并解码参数；无法解码时返回 nil
+func decodeCallData(data []byte, abis []*abi.ABI) *DecodedCall {
+	if len(data) < 4 {
+		return nil
+	}
+	for _, a := range abis {
+		method, err := a.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		values, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		call := &DecodedCall{Method: method.Name, Signature: method.Sig}
+		for i, input := range method.Inputs {
+			call.Args = append(call.Args, DecodedArg{Name: argName(input.Name, i), Type: input.Type.String(), Value: NormalizeABIValue(input.Type, values[i])})
+		}
+		return call
+	}
+	return nil
+}
+
+// decodeLog 按 topic0 在已知 ABI 中查找事件并解码参数
+func decodeLog(l types.Log, abis []*abi.ABI) *DecodedLog {
+	decoded := &DecodedLog{LogRecord: NewLogRecord(l)}
+	if len(l.Topics) == 0 {
+		return decoded
+	}
+	for _, a := range abis {
+		event, err := a.EventByID(l.Topics[0])
+		if err != nil {
+			continue
+		}
+		args, ok := decodeEventArgs(event, l)
+		if !ok {
+			continue
+		}
+		decoded.Event = event.Name
+		decoded.Signature = event.Sig
+		decoded.Args = args
+		return decoded
+	}
+	return decoded
+}
+
+func decodeEventArgs(event *abi.Event, l types.Log) ([]DecodedArg, bool) {
+	nonIndexed, err := event.Inputs.NonIndexed().Unpack(l.Data)
+	if err != nil {
+		return nil, false
+	}
+
+	var args []DecodedArg
+	topic, data := 1, 0
+	for i, input := range event.Inputs {
+		arg := DecodedArg{Name: argName(input.Name, i), Type: input.Type.String(), Indexed: input.Indexed}
+		if !input.Indexed {
+			arg.Value = NormalizeABIValue(input.Type, nonIndexed[data])
+			data++
+			args = append(args, arg)
+			continue
+		}
+		if topic >= len(l.Topics) {
+			return nil, false
+		}
+		// 动态类型的 indexed 参数只记录了哈希，无法还原原值
+		switch input.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			arg.Value = l.Topics[topic].Hex()
+		default:
+			values, err := abi.Arguments{{Type: input.Type}}.Unpack(l.Topics[topic].Bytes())
+			if err != nil {
+				return nil, false
+			}
+			arg.Value = NormalizeABIValue(input.Type, values[0])
+		}
+		topic++
+		args = append(args, arg)
+	}
+	return args, true
+}
+
+func argName(name string, i int) string {
+	if name == "" {
+		return "[" + strconv.Itoa(i) + "]"
+	}
+	return name
+}
+
+// PrintTxInfo 打印交易详情；非 text 格式时输出 TxInfoRecord
+func PrintTxInfo(info *TxInfoRecord) error {
+	if !IsTextOutput() {
+		return Emit(info)
+	}
+
+	tx := info.Transaction
+	fmt.Fprintln(TextOut(), "==================================================")
+	fmt.Fprintf(TextOut(), "交易哈希:   %s\n", tx.Hash)
+	switch info.Status {
+	case TxStatusPending:
+		fmt.Fprintln(TextOut(), "状态:       等待打包 (pending)")
+	case TxStatusSuccess:
+		fmt.Fprintf(TextOut(), "状态:       成功 (区块 %d, %d 个确认)\n", *tx.BlockNumber, info.Confirmations)
+	case TxStatusFailed:
+		fmt.Fprintf(TextOut(), "状态:       失败 (区块 %d, %d 个确认)\n", *tx.BlockNumber, info.Confirmations)
+	}
+	if info.Timestamp > 0 {
+		fmt.Fprintf(TextOut(), "时间戳:     %s\n", time.Unix(int64(info.Timestamp), 0))
+	}
+	fmt.Fprintf(TextOut(), "类型:       %s\n", TxTypeName(tx.Type))
+	fmt.Fprintf(TextOut(), "发送方:     %s\n", tx.From)
+	if tx.To != "" {
+		fmt.Fprintf(TextOut(), "接收方:     %s\n", tx.To)
+	} else {
+		fmt.Fprintln(TextOut(), "接收方:     (合约创建)")
+	}
+	fmt.Fprintf(TextOut(), "金额:       %s ETH\n", formatWeiString(tx.Value, FormatEther))
+	fmt.Fprintf(TextOut(), "Nonce:      %d\n", tx.Nonce)
+	fmt.Fprintf(TextOut(), "Gas 限制:   %d\n", tx.Gas)
+	if tx.GasPrice != "" {
+		fmt.Fprintf(TextOut(), "Gas 价格:   %s Gwei\n", formatWeiString(tx.GasPrice, FormatGwei))
+	} else {
+		fmt.Fprintf(TextOut(), "费用:       优先费上限 %s Gwei / 费用上限 %s Gwei\n", formatWeiString(tx.MaxPriorityFeePerGas, FormatGwei), formatWeiString(tx.MaxFeePerGas, FormatGwei))
+	}
+
+	if info.Input != nil {
+		fmt.Fprintf(TextOut(), "调用方法:   %s\n", info.Input.Signature)
+		printDecodedArgs(info.Input.Args)
+	} else if tx.Selector != "" {
+		fmt.Fprintf(TextOut(), "函数选择器: %s (未知 ABI，可通过 -abi 提供)\n", tx.Selector)
+	}
+
+	if r := tx.Receipt; r != nil {
+		fmt.Fprintln(TextOut(), "--------------------------------------------------")
+		fmt.Fprintf(TextOut(), "实际 Gas:   %d\n", r.GasUsed)
+		if r.Fee != "" {
+			fmt.Fprintf(TextOut(), "实际费用:   %s ETH (有效 Gas 价格 %s Gwei)\n", formatWeiString(r.Fee, FormatEther), formatWeiString(r.EffectiveGasPrice, FormatGwei))
+		}
+		if r.ContractAddress != "" {
+			fmt.Fprintf(TextOut(), "合约地址:   %s\n", r.ContractAddress)
+		}
+	}
+	if info.RevertReason != "" {
+		fmt.Fprintf(TextOut(), "失败原因:   %s (在父区块状态上重放所得)\n", info.RevertReason)
+	}
+
+	for _, l := range info.Logs {
+		fmt.Fprintln(TextOut(), "--------------------------------------------------")
+		fmt.Fprintf(TextOut(), "日志 #%d    %s\n", l.LogIndex, l.Address)
+		if l.Event == "" {
+			for i, topic := range l.Topics {
+				fmt.Fprintf(TextOut(), "  topic[%d] %s\n", i, topic)
+			}
+			fmt.Fprintf(TextOut(), "  data     %s\n", l.Data)
+			continue
+		}
+		fmt.Fprintf(TextOut(), "  事件:     %s\n", l.Signature)
+		printDecodedArgs(l.Args)
+	}
+	fmt.Fprintln(TextOut(), "==================================================")
+	return nil
+}
+
+// PrintReceipt 输出交易回执。回执本身是结构化数据，text 格式下也以 JSON 打印。
+func PrintReceipt(r *ReceiptRecord) error {
+	if !IsTextOutput() {
+		return Emit(r)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化回执失败: %v", err)
+	}
+	fmt.Fprintln(TextOut(), string(data))
+	return nil
+}
+
+func printDecodedArgs(args []DecodedArg) {
+	for _, arg := range args {
+		value, ok := arg.Value.(string)
+		if !ok {
+			data, _ := json.Marshal(arg.Value)
+			value = string(data)
+		}
+		indexed := ""
+		if arg.Indexed {
+			indexed = " indexed"
+		}
+		fmt.Fprintf(TextOut(), "    %s (%s%s): %s\n", arg.Name, arg.Type, indexed, value)
+	}
+}
+
+// formatWeiString 格式化以十进制字符串表示的 wei 数值
+func formatWeiString(s string, format func(*big.Int) string) string {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return s
+	}
+	return format(v)
+}
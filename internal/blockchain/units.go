@@ -0,0 +1,144 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// knownChains 常见链 ID 与名称的对应关系，仅用于输出展示
+var knownChains = map[uint64]string{
+	1:        "Ethereum Mainnet",
+	11155111: "Sepolia",
+	17000:    "Holesky",
+	560048:   "Hoodi",
+	1337:     "Local Dev",
+	31337:    "Local Dev (Anvil/Hardhat)",
+}
+
+// ChainName 返回链 ID 对应的可读名称，未知链返回 "Unknown"
+func ChainName(chainID *big.Int) string {
+	if chainID == nil || !chainID.IsUint64() {
+		return "Unknown"
+	}
+	if name, ok := knownChains[chainID.Uint64()]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// ParseEther 将十进制 ETH 金额字符串 (如 "0.1") 精确转换为 Wei，小数位最多 18 位
+func ParseEther(amount string) (*big.Int, error) {
+	return ParseUnits(amount, 18)
+}
+
+// FormatEther 将 Wei 精确格式化为 ETH 字符串 (整数运算，不经过浮点)
+func FormatEther(wei *big.Int) string {
+	return FormatUnits(wei, 18)
+}
+
+// FormatGwei 将 Wei 精确格式化为 Gwei 字符串
+func FormatGwei(wei *big.Int) string {
+	return FormatUnits(wei, 9)
+}
+
+// loadPrivateKey 解析十六进制私钥 (可带 0x 前缀) 并返回对应的账户地址
+func loadPrivateKey(privateKeyHex string) (*ecdsa.PrivateKey, common.Address, error) {
+	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("无效的私钥: %v", err)
+	}
+
+	publicKeyECDSA, ok := privateKey.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, common.Address{}, fmt.Errorf("无法将公钥转换为 ECDSA")
+	}
+
+	return privateKey, crypto.PubkeyToAddress(*publicKeyECDSA), nil
+}
+
+// AddressFromPrivateKey 返回私钥对应的账户地址
+func AddressFromPrivateKey(privateKeyHex string) (common.Address, error) {
+	_, address, err := loadPrivateKey(privateKeyHex)
+	return address, err
+}
+
+// ParseUnits 将十进制金额字符串 (如 "1.5") 按精度转换为最小单位的整数，不经过浮点运算
+func ParseUnits(amount string, decimals uint8) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" || strings.HasPrefix(amount, "-") {
+		return nil, fmt.Errorf("无效的金额: %q", amount)
+	}
+
+	intPart, fracPart, _ := strings.Cut(amount, ".")
+	if intPart+fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return nil, fmt.Errorf("无效的金额: %q", amount)
+	}
+	if len(fracPart) > int(decimals) {
+		return nil, fmt.Errorf("金额 %q 的小数位超过精度 %d", amount, decimals)
+	}
+	fracPart += strings.Repeat("0", int(decimals)-len(fracPart))
+
+	value, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return nil, fmt.Errorf("无效的金额: %q", amount)
+	}
+	return value, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatUnits 将最小单位的整数按精度格式化为十进制字符串
+func FormatUnits(value *big.Int, decimals uint8) string {
+	if value == nil {
+		return "0"
+	}
+	if decimals == 0 {
+		return value.String()
+	}
+
+	s := new(big.Int).Abs(value).String()
+	if len(s) <= int(decimals) {
+		s = strings.Repeat("0", int(decimals)-len(s)+1) + s
+	}
+	intPart, fracPart := s[:len(s)-int(decimals)], strings.TrimRight(s[len(s)-int(decimals):], "0")
+	if value.Sign() < 0 {
+		intPart = "-" + intPart
+	}
+	if fracPart == "" {
+		return intPart
+	}
+	return intPart + "." + fracPart
+}
+
+// ParseChecksumAddress 解析地址并执行 EIP-55 校验和检查。
+// 大小写混合的地址必须与校验和一致；全小写或全大写的地址没有校验信息，
+// 此时 checksummed 返回 false，由调用方决定是否接受。
+func ParseChecksumAddress(s string) (address common.Address, checksummed bool, err error) {
+	s = strings.TrimSpace(s)
+	if !common.IsHexAddress(s) {
+		return common.Address{}, false, fmt.Errorf("无效的地址: %q", s)
+	}
+
+	address = common.HexToAddress(s)
+	body := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return address, false, nil
+	}
+	if "0x"+body != address.Hex() {
+		return common.Address{}, false, fmt.Errorf("地址校验和错误: %s (正确格式: %s)", s, address.Hex())
+	}
+	return address, true, nil
+}
@@ -0,0 +1,163 @@
+package blockchain
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals uint8
+		want     string
+		wantErr  bool
+	}{
+		{amount: "1", decimals: 18, want: "1000000000000000000"},
+		{amount: "0.1", decimals: 18, want: "100000000000000000"},
+		{amount: "0.3", decimals: 18, want: "300000000000000000"},
+		{amount: "1.000000000000000001", decimals: 18, want: "1000000000000000001"},
+		{amount: "123456789.123456789123456789", decimals: 18, want: "123456789123456789123456789"},
+		{amount: ".5", decimals: 6, want: "500000"},
+		{amount: "5.", decimals: 6, want: "5000000"},
+		{amount: " 2.5 ", decimals: 2, want: "250"},
+		{amount: "42", decimals: 0, want: "42"},
+		{amount: "0", decimals: 18, want: "0"},
+		{amount: "0.0000000000000000001", decimals: 18, wantErr: true},
+		{amount: "1.234", decimals: 2, wantErr: true},
+		{amount: "1.5", decimals: 0, wantErr: true},
+		{amount: "", decimals: 18, wantErr: true},
+		{amount: "-1", decimals: 18, wantErr: true},
+		{amount: "1e18", decimals: 18, wantErr: true},
+		{amount: "1.2.3", decimals: 18, wantErr: true},
+		{amount: "abc", decimals: 18, wantErr: true},
+		{amount: ".", decimals: 18, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseUnits(tt.amount, tt.decimals)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseUnits(%q, %d) = %s, 期望返回错误", tt.amount, tt.decimals, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseUnits(%q, %d) 返回错误: %v", tt.amount, tt.decimals, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseUnits(%q, %d) = %s, 期望 %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseEtherIsExact(t *testing.T) {
+	// 浮点数无法精确表示 0.1，按字符串解析时必须得到精确的 Wei
+	for _, amount := range []string{"0.1", "0.7", "1.1", "0.000000000000000001", "99999999.999999999999999999"} {
+		wei, err := ParseEther(amount)
+		if err != nil {
+			t.Fatalf("ParseEther(%q) 返回错误: %v", amount, err)
+		}
+		if got := FormatUnits(wei, 18); got != amount {
+			t.Errorf("FormatUnits(ParseEther(%q)) = %s", amount, got)
+		}
+	}
+	if _, err := ParseEther("0.1234567890123456789"); err == nil {
+		t.Error("ParseEther 应拒绝超过 18 位的小数")
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals uint8
+		want     string
+	}{
+		{value: "0", decimals: 18, want: "0"},
+		{value: "1", decimals: 18, want: "0.000000000000000001"},
+		{value: "1000000000000000000", decimals: 18, want: "1"},
+		{value: "1500000000000000000", decimals: 18, want: "1.5"},
+		{value: "100000", decimals: 6, want: "0.1"},
+		{value: "123456789", decimals: 6, want: "123.456789"},
+		{value: "-2500000", decimals: 6, want: "-2.5"},
+		{value: "-1", decimals: 6, want: "-0.000001"},
+		{value: "42", decimals: 0, want: "42"},
+	}
+	for _, tt := range tests {
+		value, _ := new(big.Int).SetString(tt.value, 10)
+		if got := FormatUnits(value, tt.decimals); got != tt.want {
+			t.Errorf("FormatUnits(%s, %d) = %s, 期望 %s", tt.value, tt.decimals, got, tt.want)
+		}
+	}
+	if got := FormatUnits(nil, 18); got != "0" {
+		t.Errorf("FormatUnits(nil) = %s, 期望 0", got)
+	}
+}
+
+func TestFormatEtherIsExact(t *testing.T) {
+	tests := []struct {
+		wei       string
+		wantEther string
+		wantGwei  string
+	}{
+		// 超过 float64 精度的值以前会差 1 wei
+		{wei: "17734245977318995403", wantEther: "17.734245977318995403", wantGwei: "17734245977.318995403"},
+		{wei: "1", wantEther: "0.000000000000000001", wantGwei: "0.000000001"},
+		{wei: "1000000000", wantEther: "0.000000001", wantGwei: "1"},
+		{wei: "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+			wantEther: "115792089237316195423570985008687907853269984665640564039457.584007913129639935",
+			wantGwei:  "115792089237316195423570985008687907853269984665640564039457584007913.129639935"},
+	}
+	for _, tt := range tests {
+		wei, _ := new(big.Int).SetString(tt.wei, 10)
+		if got := FormatEther(wei); got != tt.wantEther {
+			t.Errorf("FormatEther(%s) = %s, 期望 %s", tt.wei, got, tt.wantEther)
+		}
+		if got := FormatGwei(wei); got != tt.wantGwei {
+			t.Errorf("FormatGwei(%s) = %s, 期望 %s", tt.wei, got, tt.wantGwei)
+		}
+		// 格式化结果可以原样解析回相同的 wei
+		if back, err := ParseEther(FormatEther(wei)); err != nil || back.Cmp(wei) != 0 {
+			t.Errorf("ParseEther(FormatEther(%s)) = %v, %v", tt.wei, back, err)
+		}
+	}
+}
+
+func TestParseChecksumAddress(t *testing.T) {
+	const valid = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	tests := []struct {
+		name        string
+		input       string
+		checksummed bool
+		wantErr     string
+	}{
+		{name: "正确的校验和", input: valid, checksummed: true},
+		{name: "全小写", input: strings.ToLower(valid)},
+		{name: "全大写", input: "0x" + strings.ToUpper(valid[2:])},
+		{name: "首尾空白", input: "  " + valid + "\n", checksummed: true},
+		{name: "校验和错误", input: "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", wantErr: "校验和"},
+		{name: "长度不足", input: valid[:41], wantErr: "无效的地址"},
+		{name: "非十六进制", input: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeZ", wantErr: "无效的地址"},
+		{name: "空字符串", input: "", wantErr: "无效的地址"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, checksummed, err := ParseChecksumAddress(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望包含 %q 的错误，得到 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("返回错误: %v", err)
+			}
+			if addr.Hex() != valid {
+				t.Errorf("地址 = %s, 期望 %s", addr.Hex(), valid)
+			}
+			if checksummed != tt.checksummed {
+				t.Errorf("checksummed = %v, 期望 %v", checksummed, tt.checksummed)
+			}
+		})
+	}
+}